@@ -3,10 +3,14 @@ package argo
 
 import (
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -14,21 +18,143 @@ import (
 /*  Options  */
 /* --------- */
 
-// [kind] is one of "flag", "string", "int", or "float".
-type option struct {
-	kind           string
-	count          int
-	stringValues   []string
-	intValues      []int
-	floatValues    []float64
-	stringFallback string
-	intFallback    int
-	floatFallback  float64
+// An Option instance stores a registered flag or option. Option instances are returned by the
+// parser's registration methods and can be used to attach additional metadata to the flag or option.
+//
+// [kind] is one of "flag", "string", "int", "float", "duration", "time", "map", or "custom". For
+// map options, [mapKind] is one of "string", "int", or "float".
+type Option struct {
+	// A short description of the flag or option for use in generated helptext.
+	Description string
+
+	// The name of an environment variable bound to the flag or option.
+	//
+	// If the flag or option isn't found on the command line, its value is read from this
+	// environment variable if the variable is set and non-empty. Flags accept any value accepted by
+	// strconv.ParseBool(). If empty, the parser's EnvPrefix field is used to generate a name.
+	Env string
+
+	// If true, Parse() returns an error if the flag or option isn't found. Values from bound
+	// environment variables and config files satisfy the requirement.
+	Required bool
+
+	// If true, a flag also accepts a negated --no-<name> form for each of its long-form aliases,
+	// e.g. --no-color for a flag named "color". The final form found wins. Use FlagState() to
+	// distinguish between a flag that was set to false and a flag that wasn't set at all. Ignored for
+	// options.
+	Negatable bool
+
+	// If true, the flag or option is also accepted after any of the parser's commands at any depth
+	// of the command tree, e.g. "app build --verbose" for a --verbose flag registered on the root
+	// parser. Its values are stored on the parser that registered it and can also be retrieved
+	// from any of that parser's command parsers. A command's own flags and options take precedence
+	// over persistent names they shadow.
+	Persistent bool
+
+	// If true, a choice option's values are matched against its choices case-insensitively. Matched
+	// values are stored in the case used by the choice. Ignored for other option types.
+	CaseInsensitive bool
+
+	// If true, the option's value is optional. A value is only taken from the same argument, i.e.
+	// from the --name=value form or the attached short form -nvalue. If the option is found without
+	// a value, the ImplicitValue is used instead, e.g. "--color" is equivalent to "--color=always"
+	// if the implicit value is "always". Ignored for flags.
+	OptionalValue bool
+
+	// The value used when an option with an optional value is found without a value.
+	ImplicitValue string
+
+	// If not empty, each of the option's arguments is split on this delimiter and each element is
+	// appended to the option's list of values, e.g. "--tags a,b" appends "a" and "b" if the
+	// delimiter is ",". A backslash escapes a delimiter, e.g. a\,b is a single element "a,b".
+	// Ignored for flags and custom-typed options.
+	Delimiter string
+
+	// For map options, the separator between each entry's key and value. Defaults to "=".
+	Separator string
+
+	// If true, a map option returns an error if a key is repeated. By default, the last value for
+	// a repeated key wins. Ignored for other option types.
+	RejectDuplicateKeys bool
+
+	// An optional function that returns completion candidates for the option's value.
+	//
+	// Used by the dynamic completion protocol -- see the Completer type.
+	Completer Completer
+
+	kind             string
+	aliases          []string
+	count            int
+	negated          bool
+	stringValues     []string
+	intValues        []int
+	floatValues      []float64
+	durationValues   []time.Duration
+	timeValues       []time.Time
+	stringFallback   string
+	intFallback      int
+	floatFallback    float64
+	durationFallback time.Duration
+	timeFallback     time.Time
+	value            Value
+	choices          []string
+	mapKind          string
+	stringMap        map[string]string
+	intMap           map[string]int
+	floatMap         map[string]float64
+	configValues     []string
+	configSource     string
+}
+
+// Parses an argument and appends the result to the option's list of values. If the option has a
+// delimiter, the argument is split and each element is appended in turn.
+func (opt *Option) tryAppendValue(arg string) error {
+	if opt.Delimiter == "" || opt.kind == "custom" {
+		return opt.tryAppendSingleValue(arg)
+	}
+	for _, element := range splitEscaped(arg, opt.Delimiter) {
+		if err := opt.tryAppendSingleValue(element); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Splits arg on each occurrence of delimiter. A backslash escapes a following delimiter or
+// backslash. Any other backslash is treated as a literal character.
+func splitEscaped(arg string, delimiter string) []string {
+	elements := make([]string, 0)
+	var builder strings.Builder
+	for i := 0; i < len(arg); {
+		if arg[i] == '\\' && strings.HasPrefix(arg[i+1:], delimiter) {
+			builder.WriteString(delimiter)
+			i += 1 + len(delimiter)
+		} else if arg[i] == '\\' && strings.HasPrefix(arg[i+1:], "\\") {
+			builder.WriteByte('\\')
+			i += 2
+		} else if strings.HasPrefix(arg[i:], delimiter) {
+			elements = append(elements, builder.String())
+			builder.Reset()
+			i += len(delimiter)
+		} else {
+			builder.WriteByte(arg[i])
+			i += 1
+		}
+	}
+	return append(elements, builder.String())
 }
 
-func (opt *option) tryAppendValue(arg string) error {
+// Parses a single argument and appends the result to the option's list of values.
+func (opt *Option) tryAppendSingleValue(arg string) error {
 	switch opt.kind {
 	case "string":
+		if len(opt.choices) > 0 {
+			choice, err := opt.matchChoice(arg)
+			if err != nil {
+				return err
+			}
+			arg = choice
+		}
 		opt.stringValues = append(opt.stringValues, arg)
 		return nil
 
@@ -48,11 +174,239 @@ func (opt *option) tryAppendValue(arg string) error {
 		opt.floatValues = append(opt.floatValues, value)
 		return nil
 
+	case "duration":
+		value, err := parseDuration(arg)
+		if err != nil {
+			return fmt.Errorf("cannot parse '%s' as a duration", arg)
+		}
+		opt.durationValues = append(opt.durationValues, value)
+		return nil
+
+	case "time":
+		value, err := parseTime(arg)
+		if err != nil {
+			return fmt.Errorf("cannot parse '%s' as a time", arg)
+		}
+		opt.timeValues = append(opt.timeValues, value)
+		return nil
+
+	case "map":
+		return opt.tryAddMapEntry(arg)
+
+	case "custom":
+		if err := opt.value.Set(arg); err != nil {
+			return fmt.Errorf("cannot parse '%s' as a %s value: %w", arg, opt.value.Type(), err)
+		}
+		opt.stringValues = append(opt.stringValues, arg)
+		return nil
+
 	default:
 		panic(fmt.Sprintf("argo: invalid option type: %s", opt.kind))
 	}
 }
 
+// Returns true if the option consumes the following argument as its value.
+func (opt *Option) takesArgument() bool {
+	return opt.kind != "flag" && !opt.OptionalValue
+}
+
+// Count returns the number of times the flag or option was found.
+func (opt *Option) Count() int {
+	return opt.count
+}
+
+// Found returns true if the flag or option was found. For negatable flags, returns true only if
+// the flag's final form was the positive form.
+func (opt *Option) Found() bool {
+	return opt.count > 0 && !opt.negated
+}
+
+// FlagState returns the flag's tri-state value, i.e. whether the flag was set to true, set to
+// false, or not set at all. Only negatable flags can be set to false.
+func (opt *Option) FlagState() FlagState {
+	if opt.count == 0 {
+		return FlagUnset
+	}
+	if opt.negated {
+		return FlagFalse
+	}
+	return FlagTrue
+}
+
+// Sets a negatable flag to true or false. Non-negatable flags ignore false values.
+func (opt *Option) setFlag(value bool) {
+	if value {
+		opt.count += 1
+		opt.negated = false
+	} else if opt.Negatable {
+		opt.count += 1
+		opt.negated = true
+	}
+}
+
+// Returns the value of a string-valued option.
+func (opt *Option) stringValue() string {
+	if len(opt.stringValues) > 0 {
+		return opt.stringValues[len(opt.stringValues)-1]
+	}
+	return opt.stringFallback
+}
+
+// Returns the value of an integer-valued option.
+func (opt *Option) intValue() int {
+	if len(opt.intValues) > 0 {
+		return opt.intValues[len(opt.intValues)-1]
+	}
+	return opt.intFallback
+}
+
+// Returns the value of a float-valued option.
+func (opt *Option) floatValue() float64 {
+	if len(opt.floatValues) > 0 {
+		return opt.floatValues[len(opt.floatValues)-1]
+	}
+	return opt.floatFallback
+}
+
+// Returns the value of a duration-valued option.
+func (opt *Option) durationValue() time.Duration {
+	if len(opt.durationValues) > 0 {
+		return opt.durationValues[len(opt.durationValues)-1]
+	}
+	return opt.durationFallback
+}
+
+// Returns the value of a time-valued option.
+func (opt *Option) timeValue() time.Time {
+	if len(opt.timeValues) > 0 {
+		return opt.timeValues[len(opt.timeValues)-1]
+	}
+	return opt.timeFallback
+}
+
+// Parses arg as a key-value pair and adds the pair to a map option's map.
+func (opt *Option) tryAddMapEntry(arg string) error {
+	separator := opt.Separator
+	if separator == "" {
+		separator = "="
+	}
+
+	key, value, found := strings.Cut(arg, separator)
+	if !found || key == "" {
+		return fmt.Errorf("cannot parse '%s' as a key%svalue pair", arg, separator)
+	}
+
+	if opt.RejectDuplicateKeys {
+		for _, existing := range opt.stringValues {
+			if existingKey, _, _ := strings.Cut(existing, separator); existingKey == key {
+				return fmt.Errorf("duplicate key '%s' in '%s'", key, arg)
+			}
+		}
+	}
+
+	switch opt.mapKind {
+	case "string":
+		if opt.stringMap == nil {
+			opt.stringMap = make(map[string]string)
+		}
+		opt.stringMap[key] = value
+
+	case "int":
+		parsed, err := strconv.ParseInt(value, 0, 0)
+		if err != nil {
+			return fmt.Errorf("cannot parse '%s' in '%s' as an integer", value, arg)
+		}
+		if opt.intMap == nil {
+			opt.intMap = make(map[string]int)
+		}
+		opt.intMap[key] = int(parsed)
+
+	case "float":
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("cannot parse '%s' in '%s' as a float", value, arg)
+		}
+		if opt.floatMap == nil {
+			opt.floatMap = make(map[string]float64)
+		}
+		opt.floatMap[key] = parsed
+	}
+
+	opt.stringValues = append(opt.stringValues, arg)
+	return nil
+}
+
+// Returns the choice matching arg. Returns an error listing the valid choices and suggesting the
+// closest match if arg doesn't match any choice.
+func (opt *Option) matchChoice(arg string) (string, error) {
+	for _, choice := range opt.choices {
+		if arg == choice || (opt.CaseInsensitive && strings.EqualFold(arg, choice)) {
+			return choice, nil
+		}
+	}
+
+	message := fmt.Sprintf("invalid value '%s', must be one of: %s", arg, strings.Join(opt.choices, ", "))
+	target := arg
+	candidates := opt.choices
+	if opt.CaseInsensitive {
+		target = strings.ToLower(arg)
+		candidates = make([]string, len(opt.choices))
+		for i, choice := range opt.choices {
+			candidates[i] = strings.ToLower(choice)
+		}
+	}
+	if match := closestMatch(target, candidates); match != "" {
+		for i, candidate := range candidates {
+			if candidate == match {
+				message += fmt.Sprintf(" (did you mean '%s'?)", opt.choices[i])
+				break
+			}
+		}
+	}
+	return "", fmt.Errorf("%s", message)
+}
+
+// Parses a duration in Go's duration syntax, e.g. "1h30m", or as a plain number of seconds.
+func parseDuration(arg string) (time.Duration, error) {
+	if value, err := time.ParseDuration(arg); err == nil {
+		return value, nil
+	}
+	seconds, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	// Reject infinities, NaN, and values outside the range of an int64 count of nanoseconds.
+	nanoseconds := seconds * float64(time.Second)
+	if math.IsNaN(nanoseconds) || nanoseconds >= math.MaxInt64 || nanoseconds < math.MinInt64 {
+		return 0, fmt.Errorf("duration '%s' out of range", arg)
+	}
+	return time.Duration(nanoseconds), nil
+}
+
+// The layouts accepted for time-valued options, in addition to Unix timestamps.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// Parses a time as an RFC 3339 timestamp, a date with an optional time, or a Unix timestamp in
+// seconds. Times without a zone are interpreted as UTC.
+func parseTime(arg string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if value, err := time.Parse(layout, arg); err == nil {
+			return value, nil
+		}
+	}
+	seconds, err := strconv.ParseInt(strings.TrimPrefix(arg, "@"), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
 /* ----------- */
 /*  ArgStream  */
 /* ----------- */
@@ -84,6 +438,15 @@ func (stream *argstream) hasNext() bool {
 	return stream.index < stream.length
 }
 
+// A FlagState value records whether a flag was set to true, set to false, or not set at all.
+type FlagState int
+
+const (
+	FlagUnset FlagState = iota
+	FlagTrue
+	FlagFalse
+)
+
 /* ----------- */
 /*  ArgParser  */
 /* ----------- */
@@ -92,11 +455,24 @@ func (stream *argstream) hasNext() bool {
 type ArgParser struct {
 	// The parser's helptext string.
 	//
-	// Specifying a helptext string for a parser activates an automatic --help flag that prints the
-	// parser's helptext and exits. (Also activates an automatic -h shortcut unless registered by
-	// another flag/option.)
+	// Every parser has an automatic --help flag that prints the parser's helptext and exits. (Also
+	// an automatic -h shortcut unless registered by another flag/option.) If this field is empty,
+	// the helptext is generated automatically from the descriptions of the parser's registered
+	// flags, options, and commands. Specifying a helptext string overrides the generated helptext.
 	Helptext string
 
+	// The application name for use in generated helptext.
+	//
+	// This field is only used by the root parser. If empty, the name is taken from the first
+	// argument passed to Parse(), i.e. the application's path.
+	Name string
+
+	// A short description of the parser for use in generated helptext.
+	//
+	// For command subparsers, this description is also displayed in the parent parser's list of
+	// commands.
+	Description string
+
 	// The parser's version string.
 	//
 	// Specifying a version string for a parser activates an automatic --version flag that prints the
@@ -118,6 +494,59 @@ type ArgParser struct {
 	// Set this value to false to disable the automatic 'help' command.
 	EnableHelpCommand bool
 
+	// If true, enables an automatic 'completion' command that prints a shell completion script.
+	//
+	// The command accepts a single argument, the name of the shell -- one of "bash", "zsh", or
+	// "fish". Defaults to false.
+	EnableCompletionCommand bool
+
+	// If true, short-form options parse attached values in the style of getopt.
+	//
+	// If a character in a short-form argument is a value-taking option, the remainder of the
+	// argument becomes the option's value, e.g. -ofile or -n5. A single leading '=' is stripped from
+	// the value, so -o=file is equivalent to -ofile. If the option is the final character, its value
+	// is taken from the next argument. By default, every character in a short-form argument is
+	// treated as a flag or option name and values are taken from the following arguments, e.g.
+	// -abc foo bar. This setting is inherited by command parsers.
+	AttachedShortValues bool
+
+	// If true, long-form flag and option names can be abbreviated to any unique prefix, e.g. --verb
+	// for --verbose. Parse() returns an error listing the candidates if a prefix is ambiguous. This
+	// setting is inherited by command parsers.
+	AbbreviatedOptions bool
+
+	// If true, command names can be abbreviated to any unique prefix, e.g. 'bu' for 'build'. Applies
+	// to the argument of the automatic 'help' command. Parse() returns an error listing the
+	// candidates if a prefix is ambiguous. This setting is inherited by command parsers.
+	AbbreviatedCommands bool
+
+	// The prefix for automatically-generated environment variable names.
+	//
+	// If not empty, each of the parser's flags and options that doesn't have an explicit Env name
+	// is bound to an environment variable named PREFIX_OPTION_NAME, where OPTION_NAME is the
+	// option's first long-form alias in upper case with dashes replaced by underscores.
+	EnvPrefix string
+
+	// Optional functions that return completion candidates for the parser's positional arguments.
+	//
+	// The completer at index i is used for the positional argument at index i. The final completer
+	// is used for all remaining positional arguments. Used by the dynamic completion protocol -- see
+	// the Completer type.
+	ArgCompleters []Completer
+
+	// The writer for the parser's standard output, i.e. helptext, version strings, and completion
+	// scripts. If nil, the parent parser's writer is used, defaulting to os.Stdout.
+	Stdout io.Writer
+
+	// The function called to exit after printing helptext, a version string, or completions. If nil,
+	// the parent parser's function is used, defaulting to os.Exit.
+	Exit func(code int)
+
+	// If true, Parse() returns ErrHelpRequested, ErrVersionRequested, or ErrCompletionRequested
+	// after printing helptext, a version string, or completions instead of exiting. This setting is
+	// inherited by command parsers.
+	NoExit bool
+
 	// After parsing, stores the parser's positional arguments.
 	Args []string
 
@@ -128,16 +557,35 @@ type ArgParser struct {
 	FoundCommandParser *ArgParser
 
 	// Stores option instances indexed by option name.
-	options map[string]*option
+	options map[string]*Option
+
+	// Stores option instances in registration order.
+	optionList []*Option
+
+	// Stores option groups in registration order.
+	groups []*optionGroup
+
+	// Stores struct fields bound to the parser's options.
+	bindings []fieldBinding
 
 	// Stores command parsers indexed by command name.
 	commands map[string]*ArgParser
+
+	// Stores command parsers in registration order.
+	commandList []*ArgParser
+
+	// For command parsers, stores the command's aliases and the parent parser instance.
+	aliases []string
+	parent  *ArgParser
+
+	// Stores the application name, taken from the first argument passed to Parse().
+	appName string
 }
 
 // NewParser initializes a new ArgParser instance.
 func NewParser() *ArgParser {
 	return &ArgParser{
-		options:  make(map[string]*option),
+		options:  make(map[string]*Option),
 		commands: make(map[string]*ArgParser),
 		Args:     make([]string, 0),
 	}
@@ -147,67 +595,151 @@ func NewParser() *ArgParser {
 /*  ArgParser: register options.  */
 /* ------------------------------ */
 
+// Registers an option instance under each of the space-separated aliases in name.
+func (parser *ArgParser) registerOption(name string, opt *Option) *Option {
+	opt.aliases = strings.Split(name, " ")
+	for _, alias := range opt.aliases {
+		parser.options[alias] = opt
+	}
+	parser.optionList = append(parser.optionList, opt)
+	return opt
+}
+
 // NewFlag registers a new flag, i.e. a valueless option that is either present (found) or absent
 // (not found). You can check for the presence of a flag using the parser's Found() or Count()
 // methods.
 //
 // The name parameter accepts an unlimited number of space-separated aliases and single-character
-// shortcuts.
-func (parser *ArgParser) NewFlag(name string) {
-	opt := &option{}
+// shortcuts. Returns the new flag's Option instance.
+func (parser *ArgParser) NewFlag(name string) *Option {
+	opt := &Option{}
 	opt.kind = "flag"
-	for _, alias := range strings.Split(name, " ") {
-		parser.options[alias] = opt
-	}
+	return parser.registerOption(name, opt)
 }
 
 // NewStringOption registers a new string-valued option.
 //
 // The name parameter accepts an unlimited number of space-separated aliases and single-character
-// shortcuts. The fallback parameter specifies the option's default value.
-func (parser *ArgParser) NewStringOption(name string, fallback string) {
-	opt := &option{}
+// shortcuts. The fallback parameter specifies the option's default value. Returns the new option's
+// Option instance.
+func (parser *ArgParser) NewStringOption(name string, fallback string) *Option {
+	opt := &Option{}
 	opt.kind = "string"
 	opt.stringFallback = fallback
-	for _, alias := range strings.Split(name, " ") {
-		parser.options[alias] = opt
-	}
+	return parser.registerOption(name, opt)
+}
+
+// NewChoiceOption registers a new string-valued option whose values are restricted to a fixed set
+// of choices. Parsing returns an error if the option's argument isn't one of the choices. Set the
+// option's CaseInsensitive field to match arguments case-insensitively.
+//
+// The name parameter accepts an unlimited number of space-separated aliases and single-character
+// shortcuts. The fallback parameter specifies the option's default value. Returns the new option's
+// Option instance.
+func (parser *ArgParser) NewChoiceOption(name string, fallback string, choices ...string) *Option {
+	opt := &Option{}
+	opt.kind = "string"
+	opt.stringFallback = fallback
+	opt.choices = choices
+	return parser.registerOption(name, opt)
+}
+
+// NewMapOption registers a new map-valued option, i.e. each of the option's arguments will be
+// parsed as a key=value pair and added to a map[string]string. Set the option's Separator field to
+// use a separator other than "=". By default, the last value for a repeated key wins -- set the
+// option's RejectDuplicateKeys field to return an error instead.
+//
+// The name parameter accepts an unlimited number of space-separated aliases and single-character
+// shortcuts. Returns the new option's Option instance. The option's raw string arguments are
+// available from the parser's StringValues() method.
+func (parser *ArgParser) NewMapOption(name string) *Option {
+	opt := &Option{}
+	opt.kind = "map"
+	opt.mapKind = "string"
+	return parser.registerOption(name, opt)
+}
+
+// NewIntMapOption registers a new map-valued option whose values will be parsed as ints, i.e. each
+// of the option's arguments will be parsed as a key=value pair and added to a map[string]int.
+// See NewMapOption() for details.
+func (parser *ArgParser) NewIntMapOption(name string) *Option {
+	opt := &Option{}
+	opt.kind = "map"
+	opt.mapKind = "int"
+	return parser.registerOption(name, opt)
+}
+
+// NewFloatMapOption registers a new map-valued option whose values will be parsed as float64s,
+// i.e. each of the option's arguments will be parsed as a key=value pair and added to a
+// map[string]float64. See NewMapOption() for details.
+func (parser *ArgParser) NewFloatMapOption(name string) *Option {
+	opt := &Option{}
+	opt.kind = "map"
+	opt.mapKind = "float"
+	return parser.registerOption(name, opt)
 }
 
 // NewIntOption registers a new integer-valued option, i.e. the option's value will be parsed
 // as an int.
 //
 // The name parameter accepts an unlimited number of space-separated aliases and single-character
-// shortcuts. The fallback parameter specifies the option's default value.
-func (parser *ArgParser) NewIntOption(name string, fallback int) {
-	opt := &option{}
+// shortcuts. The fallback parameter specifies the option's default value. Returns the new option's
+// Option instance.
+func (parser *ArgParser) NewIntOption(name string, fallback int) *Option {
+	opt := &Option{}
 	opt.kind = "int"
 	opt.intFallback = fallback
-	for _, alias := range strings.Split(name, " ") {
-		parser.options[alias] = opt
-	}
+	return parser.registerOption(name, opt)
 }
 
 // NewFloatOption registers a new float-valued option, i.e. the option's value will be parsed
 // as a float64.
 //
 // The name parameter accepts an unlimited number of space-separated aliases and single-character
-// shortcuts. The fallback parameter specifies the option's default value.
-func (parser *ArgParser) NewFloatOption(name string, fallback float64) {
-	opt := &option{}
+// shortcuts. The fallback parameter specifies the option's default value. Returns the new option's
+// Option instance.
+func (parser *ArgParser) NewFloatOption(name string, fallback float64) *Option {
+	opt := &Option{}
 	opt.kind = "float"
 	opt.floatFallback = fallback
-	for _, alias := range strings.Split(name, " ") {
-		parser.options[alias] = opt
-	}
+	return parser.registerOption(name, opt)
+}
+
+// NewDurationOption registers a new duration-valued option, i.e. the option's value will be parsed
+// as a time.Duration. Values can use Go's duration syntax, e.g. "1h30m", or a plain number of
+// seconds, e.g. "90".
+//
+// The name parameter accepts an unlimited number of space-separated aliases and single-character
+// shortcuts. The fallback parameter specifies the option's default value. Returns the new option's
+// Option instance.
+func (parser *ArgParser) NewDurationOption(name string, fallback time.Duration) *Option {
+	opt := &Option{}
+	opt.kind = "duration"
+	opt.durationFallback = fallback
+	return parser.registerOption(name, opt)
+}
+
+// NewTimeOption registers a new time-valued option, i.e. the option's value will be parsed as a
+// time.Time. Values can be RFC 3339 timestamps, e.g. "2006-01-02T15:04:05Z", dates with an
+// optional time, e.g. "2006-01-02" or "2006-01-02 15:04:05", or Unix timestamps in seconds with an
+// optional '@' prefix, e.g. "@1136214245". Times without a zone are interpreted as UTC.
+//
+// The name parameter accepts an unlimited number of space-separated aliases and single-character
+// shortcuts. The fallback parameter specifies the option's default value. Returns the new option's
+// Option instance.
+func (parser *ArgParser) NewTimeOption(name string, fallback time.Time) *Option {
+	opt := &Option{}
+	opt.kind = "time"
+	opt.timeFallback = fallback
+	return parser.registerOption(name, opt)
 }
 
 /* ------------------------------------ */
 /*  ArgParser: retrieve option values.  */
 /* ------------------------------------ */
 
-func (parser *ArgParser) getOpt(name string) *option {
-	if opt, found := parser.options[name]; found {
+func (parser *ArgParser) getOpt(name string) *Option {
+	if opt, found := parser.lookupOption(name); found {
 		return opt
 	}
 	panic(fmt.Sprintf("argo: '%s' is not a registered flag or option name", name))
@@ -218,15 +750,25 @@ func (parser *ArgParser) getOpt(name string) *option {
 //
 // Panics if name is not a registered flag or option name.
 func (parser *ArgParser) Count(name string) int {
-	return parser.getOpt(name).count
+	return parser.getOpt(name).Count()
 }
 
-// Found returns true if the specified flag or option was found.
+// Found returns true if the specified flag or option was found. For negatable flags, returns true
+// only if the flag's final form was the positive form.
 // Any of the flag/option's registered aliases or shortcuts can be used as the name parameter.
 //
 // Panics if name is not a registered flag or option name.
 func (parser *ArgParser) Found(name string) bool {
-	return parser.getOpt(name).count > 0
+	return parser.getOpt(name).Found()
+}
+
+// FlagState returns the tri-state value of the specified flag, i.e. FlagTrue if the flag was set to
+// true, FlagFalse if a negatable flag was set to false, or FlagUnset if the flag wasn't set.
+// Any of the flag's registered aliases or shortcuts can be used as the name parameter.
+//
+// Panics if name is not a registered flag or option name.
+func (parser *ArgParser) FlagState(name string) FlagState {
+	return parser.getOpt(name).FlagState()
 }
 
 // StringValue returns the value of the specified string-valued option.
@@ -234,11 +776,7 @@ func (parser *ArgParser) Found(name string) bool {
 //
 // Panics if name is not a registered flag or option name.
 func (parser *ArgParser) StringValue(name string) string {
-	opt := parser.getOpt(name)
-	if len(opt.stringValues) > 0 {
-		return opt.stringValues[len(opt.stringValues)-1]
-	}
-	return opt.stringFallback
+	return parser.getOpt(name).stringValue()
 }
 
 // IntValue returns the value of the specified integer-valued option.
@@ -246,11 +784,7 @@ func (parser *ArgParser) StringValue(name string) string {
 //
 // Panics if name is not a registered flag or option name.
 func (parser *ArgParser) IntValue(name string) int {
-	opt := parser.getOpt(name)
-	if len(opt.intValues) > 0 {
-		return opt.intValues[len(opt.intValues)-1]
-	}
-	return opt.intFallback
+	return parser.getOpt(name).intValue()
 }
 
 // FloatValue returns the value of the specified float-valued option.
@@ -258,11 +792,7 @@ func (parser *ArgParser) IntValue(name string) int {
 //
 // Panics if name is not a registered flag or option name.
 func (parser *ArgParser) FloatValue(name string) float64 {
-	opt := parser.getOpt(name)
-	if len(opt.floatValues) > 0 {
-		return opt.floatValues[len(opt.floatValues)-1]
-	}
-	return opt.floatFallback
+	return parser.getOpt(name).floatValue()
 }
 
 // StringValues returns the specified string-valued option's list of values.
@@ -289,6 +819,77 @@ func (parser *ArgParser) FloatValues(name string) []float64 {
 	return parser.getOpt(name).floatValues
 }
 
+// MapValue returns the specified map-valued option's map of values. Returns an empty map if the
+// option wasn't found.
+// Any of the option's registered aliases or shortcuts can be used as the name parameter.
+//
+// Panics if name is not a registered flag or option name.
+func (parser *ArgParser) MapValue(name string) map[string]string {
+	opt := parser.getOpt(name)
+	if opt.stringMap == nil {
+		return make(map[string]string)
+	}
+	return opt.stringMap
+}
+
+// IntMapValue returns the specified int-map-valued option's map of values. Returns an empty map if
+// the option wasn't found.
+// Any of the option's registered aliases or shortcuts can be used as the name parameter.
+//
+// Panics if name is not a registered flag or option name.
+func (parser *ArgParser) IntMapValue(name string) map[string]int {
+	opt := parser.getOpt(name)
+	if opt.intMap == nil {
+		return make(map[string]int)
+	}
+	return opt.intMap
+}
+
+// FloatMapValue returns the specified float-map-valued option's map of values. Returns an empty map
+// if the option wasn't found.
+// Any of the option's registered aliases or shortcuts can be used as the name parameter.
+//
+// Panics if name is not a registered flag or option name.
+func (parser *ArgParser) FloatMapValue(name string) map[string]float64 {
+	opt := parser.getOpt(name)
+	if opt.floatMap == nil {
+		return make(map[string]float64)
+	}
+	return opt.floatMap
+}
+
+// DurationValue returns the value of the specified duration-valued option.
+// Any of the option's registered aliases or shortcuts can be used as the name parameter.
+//
+// Panics if name is not a registered flag or option name.
+func (parser *ArgParser) DurationValue(name string) time.Duration {
+	return parser.getOpt(name).durationValue()
+}
+
+// DurationValues returns the specified duration-valued option's list of values.
+// Any of the option's registered aliases or shortcuts can be used as the name parameter.
+//
+// Panics if name is not a registered flag or option name.
+func (parser *ArgParser) DurationValues(name string) []time.Duration {
+	return parser.getOpt(name).durationValues
+}
+
+// TimeValue returns the value of the specified time-valued option.
+// Any of the option's registered aliases or shortcuts can be used as the name parameter.
+//
+// Panics if name is not a registered flag or option name.
+func (parser *ArgParser) TimeValue(name string) time.Time {
+	return parser.getOpt(name).timeValue()
+}
+
+// TimeValues returns the specified time-valued option's list of values.
+// Any of the option's registered aliases or shortcuts can be used as the name parameter.
+//
+// Panics if name is not a registered flag or option name.
+func (parser *ArgParser) TimeValues(name string) []time.Time {
+	return parser.getOpt(name).timeValues
+}
+
 /* ---------------------------------- */
 /*  ArgParser: positional arguments.  */
 /* ---------------------------------- */
@@ -330,9 +931,12 @@ func (parser *ArgParser) ArgsAsFloats() ([]float64, error) {
 func (parser *ArgParser) NewCommand(name string) *ArgParser {
 	parser.EnableHelpCommand = true
 	cmdParser := NewParser()
-	for _, alias := range strings.Split(name, " ") {
+	cmdParser.aliases = strings.Split(name, " ")
+	cmdParser.parent = parser
+	for _, alias := range cmdParser.aliases {
 		parser.commands[alias] = cmdParser
 	}
+	parser.commandList = append(parser.commandList, cmdParser)
 	return cmdParser
 }
 
@@ -373,34 +977,58 @@ func (parser *ArgParser) parseStream(stream *argstream) error {
 			continue
 		}
 
+		// Is the argument an abbreviated command name?
+		name := arg
+		if len(parser.Args) == 0 {
+			expanded, err := parser.expandCommandName(arg, true)
+			if err != nil {
+				return parser.unknownCommandError(arg, stream, err.Error())
+			}
+			name = expanded
+		}
+
 		// Is the argument a registered command?
 		if len(parser.Args) == 0 {
-			if cmdParser, found := parser.commands[arg]; found {
-				parser.FoundCommandName = arg
+			if cmdParser, found := parser.commands[name]; found {
+				parser.FoundCommandName = name
 				parser.FoundCommandParser = cmdParser
-
-				if err := cmdParser.parseStream(stream); err != nil {
-					return err
-				}
-
-				if cmdParser.Callback != nil {
-					return cmdParser.Callback(arg, cmdParser)
-				}
-
-				break
+				return cmdParser.parseStream(stream)
 			}
 		}
 
 		// Is the argument the automatic 'help' command?
-		if len(parser.Args) == 0 && parser.EnableHelpCommand && arg == "help" {
+		if len(parser.Args) == 0 && parser.EnableHelpCommand && name == "help" {
 			if stream.hasNext() {
 				name := stream.next()
+				expanded, err := parser.expandCommandName(name, false)
+				if err != nil {
+					return parser.unknownCommandError(name, stream, "help: "+err.Error())
+				}
+				name = expanded
 				if cmdParser, ok := parser.commands[name]; ok {
-					cmdParser.exitWithHelptext()
+					return cmdParser.exitWithHelptext()
 				}
-				return fmt.Errorf("help: '%v' is not a recognised command name", name)
+				message := fmt.Sprintf("help: '%v' is not a recognised command name%s", name, parser.suggestCommand(name))
+				return parser.unknownCommandError(name, stream, message)
+			}
+			return parser.commandArgumentError("help", stream, "help: missing argument for the help command")
+		}
+
+		// Is the argument the automatic 'completion' command?
+		if len(parser.Args) == 0 && parser.EnableCompletionCommand && name == "completion" {
+			if stream.hasNext() {
+				stream.next()
+				return parser.exitWithCompletionScript(stream)
+			}
+			return parser.commandArgumentError("completion", stream, "completion: missing argument for the completion command")
+		}
+
+		// Is the argument a misspelled command name?
+		if len(parser.Args) == 0 && len(parser.commands) > 0 {
+			if suggestion := parser.suggestCommand(arg); suggestion != "" {
+				message := fmt.Sprintf("'%v' is not a recognised command name%s", arg, suggestion)
+				return parser.unknownCommandError(arg, stream, message)
 			}
-			return fmt.Errorf("help: missing argument for the help command")
 		}
 
 		// If we get here, we have a positional argument.
@@ -412,8 +1040,68 @@ func (parser *ArgParser) parseStream(stream *argstream) error {
 
 // Parse parses a slice of string arguments. The arguments will be treated as if they came directly
 // from os.Args, i.e. the first argument will be treated as the application's path and will be ignored.
+//
+// Parse stores the parsed values on the parser itself, replacing any values stored by a previous
+// call. Use ParseArgs() to parse into a separate Result instance instead.
 func (parser *ArgParser) Parse(args []string) error {
-	return parser.parseStream(newArgStream(args[1:]))
+	parser.appName = filepath.Base(args[0])
+
+	// Is the first argument the hidden '__complete' command used by dynamic completion scripts? This
+	// entry point is only recognised for the application's own command line.
+	if len(args) > 1 && args[1] == "__complete" && parser.parent == nil {
+		parser.reset()
+		return parser.exitWithCompletions(args[2:])
+	}
+
+	return parser.parseArgs(args[1:])
+}
+
+// Parses a slice of string arguments that doesn't include the application's path.
+func (parser *ArgParser) parseArgs(args []string) error {
+	parser.reset()
+	if err := parser.parseStream(newArgStream(args)); err != nil {
+		return err
+	}
+
+	// The chain of parsers runs from the root parser to the most deeply nested command parser.
+	chain := []*ArgParser{parser}
+	for chain[len(chain)-1].FoundCommandParser != nil {
+		chain = append(chain, chain[len(chain)-1].FoundCommandParser)
+	}
+
+	// Fill in values from secondary sources, innermost parser first.
+	for i := len(chain) - 1; i >= 0; i-- {
+		if err := chain[i].finalize(); err != nil {
+			return err
+		}
+	}
+
+	// Call any registered command callbacks, innermost command first.
+	for i := len(chain) - 1; i > 0; i-- {
+		if chain[i].Callback != nil {
+			if err := chain[i].Callback(chain[i-1].FoundCommandName, chain[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Performs post-parsing processing for the parser's registered options.
+func (parser *ArgParser) finalize() error {
+	excluded := parser.excludedOptions()
+	if err := parser.applyEnv(excluded); err != nil {
+		return err
+	}
+	if err := parser.applyConfig(excluded); err != nil {
+		return err
+	}
+	if err := parser.validate(); err != nil {
+		return err
+	}
+	parser.fillBindings()
+	return nil
 }
 
 // ParseOsArgs parses the application's command line arguments.
@@ -424,114 +1112,330 @@ func (parser *ArgParser) ParseOsArgs() error {
 
 // Parse a long-form option, i.e. an option beginning with a double dash.
 func (parser *ArgParser) parseLongOption(arg string, stream *argstream) error {
+	// Errors for invalid values refer to the option's own argument, not to a separate value argument.
+	_, index := stream.current()
+
 	// Do we have an option of the form --name=value?
 	if strings.Contains(arg, "=") {
-		return parser.parseEqualsOption("--", arg)
+		return parser.parseEqualsOption("--", arg, stream)
+	}
+
+	// Is the argument an abbreviated flag or option name?
+	expanded, err := parser.expandLongName(arg)
+	if err != nil {
+		return parser.unknownOptionError("--"+arg, stream, err.Error())
 	}
+	arg = expanded
 
 	// Is the argument a registered flag or option name?
-	if opt, found := parser.options[arg]; found {
-		opt.count += 1
+	if opt, found := parser.lookupOption(arg); found {
 		if opt.kind == "flag" {
+			opt.setFlag(true)
 			return nil
 		}
+		opt.count += 1
+		if opt.OptionalValue {
+			return parser.invalidValueError("--"+arg, opt.ImplicitValue, stream, index, opt.tryAppendValue(opt.ImplicitValue))
+		}
 		if stream.hasNext() {
-			return opt.tryAppendValue(stream.next())
+			value := stream.next()
+			return parser.invalidValueError("--"+arg, value, stream, index, opt.tryAppendValue(value))
 		}
-		return fmt.Errorf("missing argument for option --%v", arg)
+		return parser.missingValueError("--"+arg, stream, fmt.Sprintf("missing argument for option --%v", arg))
+	}
+
+	// Is the argument the negated form of a negatable flag?
+	if opt, found := parser.negatedFlag(arg); found {
+		opt.setFlag(false)
+		return nil
 	}
 
 	// Is the argument an automatic --help flag?
-	if arg == "help" && parser.Helptext != "" {
-		parser.exitWithHelptext()
+	if arg == "help" {
+		return parser.exitWithHelptext()
 	}
 
 	// Is the argument an automatic --version flag?
 	if arg == "version" && parser.Version != "" {
-		parser.exitWithVersion()
+		return parser.exitWithVersion()
 	}
 
 	// The argument is not a recognised flag or option name.
-	return fmt.Errorf("--%v is not a recognised flag or option name", arg)
+	message := fmt.Sprintf("--%v is not a recognised flag or option name%s", arg, parser.suggestOption(arg))
+	return parser.unknownOptionError("--"+arg, stream, message)
 }
 
 // Parse a short-form option, i.e. an option beginning with a single dash.
 func (parser *ArgParser) parseShortOption(arg string, stream *argstream) error {
+	// Errors for invalid values refer to the option's own argument, not to a separate value argument.
+	_, index := stream.current()
+
+	attached := parser.inheritedSetting(func(p *ArgParser) bool { return p.AttachedShortValues })
+
 	// Do we have an option of the form -n=value?
-	if strings.Contains(arg, "=") {
-		return parser.parseEqualsOption("-", arg)
+	if strings.Contains(arg, "=") && !attached {
+		return parser.parseEqualsOption("-", arg, stream)
 	}
 
 	// We examine each character individually to support condensed options with trailing arguments,
 	// e.g. -abc foo bar. If we don't recognise the character as a registered flag or option name,
 	// we check for an automatic -h or -v flag before returning an error.
-	for _, char := range arg {
+	for i, char := range arg {
 		name := string(char)
 
-		if opt, found := parser.options[name]; found {
-			opt.count += 1
+		if opt, found := parser.lookupOption(name); found {
 			if opt.kind == "flag" {
+				// Boolean flags should never be followed by an equals sign.
+				if value, assigned := strings.CutPrefix(arg[i+len(name):], "="); assigned {
+					err := fmt.Errorf("invalid value assignment for flag -%s", name)
+					return parser.invalidValueError("-"+name, value, stream, index, err)
+				}
+				opt.setFlag(true)
 				continue
 			}
+			opt.count += 1
+			if opt.OptionalValue || attached {
+				// The remainder of the argument is the option's attached value.
+				if value := arg[i+len(name):]; value != "" {
+					if attached {
+						value = strings.TrimPrefix(value, "=")
+					}
+					if value == "" {
+						return parser.missingValueError("-"+name, stream, fmt.Sprintf("missing value for -%s", name))
+					}
+					return parser.invalidValueError("-"+name, value, stream, index, opt.tryAppendValue(value))
+				}
+				if opt.OptionalValue {
+					return parser.invalidValueError("-"+name, opt.ImplicitValue, stream, index, opt.tryAppendValue(opt.ImplicitValue))
+				}
+			}
 			if stream.hasNext() {
-				if err := opt.tryAppendValue(stream.next()); err != nil {
+				value := stream.next()
+				if err := parser.invalidValueError("-"+name, value, stream, index, opt.tryAppendValue(value)); err != nil {
 					return err
 				}
 				continue
 			}
 			if len([]rune(arg)) > 1 {
-				return fmt.Errorf("missing argument for option '%v' in -%v", name, arg)
+				return parser.missingValueError("-"+name, stream, fmt.Sprintf("missing argument for option '%v' in -%v", name, arg))
 			}
-			return fmt.Errorf("missing argument for option -%v", arg)
+			return parser.missingValueError("-"+name, stream, fmt.Sprintf("missing argument for option -%v", arg))
 		}
 
-		if name == "h" && parser.Helptext != "" {
-			parser.exitWithHelptext()
+		if name == "h" {
+			return parser.exitWithHelptext()
 		}
 
 		if name == "v" && parser.Version != "" {
-			parser.exitWithVersion()
+			return parser.exitWithVersion()
 		}
 
 		if len([]rune(arg)) > 1 {
-			return fmt.Errorf("'%v' in -%v is not a recognised flag or option name", name, arg)
+			message := fmt.Sprintf("'%v' in -%v is not a recognised flag or option name%s", name, arg, parser.suggestOption(arg))
+			return parser.unknownOptionError("-"+name, stream, message)
 		}
 
-		return fmt.Errorf("-%v is not a recognised flag or option name", name)
+		message := fmt.Sprintf("-%v is not a recognised flag or option name%s", name, parser.suggestOption(name))
+		return parser.unknownOptionError("-"+name, stream, message)
 	}
 
 	return nil
 }
 
 // Parse an option of the form --name=value or -n=value.
-func (parser *ArgParser) parseEqualsOption(prefix string, arg string) error {
+func (parser *ArgParser) parseEqualsOption(prefix string, arg string, stream *argstream) error {
 	if !strings.Contains(arg, "=") {
 		panic(fmt.Sprintf("argo: invalid call to parseEqualsOption with prefix '%s' and arg '%s'", prefix, arg))
 	}
 
+	_, index := stream.current()
 	split := strings.SplitN(arg, "=", 2)
 	name := split[0]
 	value := split[1]
 
+	// Is the name an abbreviated option name?
+	if prefix == "--" {
+		expanded, err := parser.expandLongName(name)
+		if err != nil {
+			return parser.unknownOptionError(prefix+name, stream, err.Error())
+		}
+		name = expanded
+	}
+
 	// Do we have the name of a registered option?
-	opt, found := parser.options[name]
+	opt, found := parser.lookupOption(name)
 	if !found {
-		return fmt.Errorf("%s%s is not a recognised option name", prefix, name)
+		if _, found := parser.negatedFlag(name); found && prefix == "--" {
+			err := fmt.Errorf("invalid value assignment for flag %s%s", prefix, name)
+			return parser.invalidValueError(prefix+name, value, stream, index, err)
+		}
+		message := fmt.Sprintf("%s%s is not a recognised option name%s", prefix, name, parser.suggestOption(name))
+		return parser.unknownOptionError(prefix+name, stream, message)
 	}
-	opt.count += 1
 
 	// Boolean flags should never be followed by an equals sign.
 	if opt.kind == "flag" {
-		return fmt.Errorf("invalid value assignment for flag %s%s", prefix, name)
+		err := fmt.Errorf("invalid value assignment for flag %s%s", prefix, name)
+		return parser.invalidValueError(prefix+name, value, stream, index, err)
 	}
+	opt.count += 1
 
 	// Check that a value has been supplied.
 	if value == "" {
-		return fmt.Errorf("missing value for %s%s", prefix, name)
+		return parser.missingValueError(prefix+name, stream, fmt.Sprintf("missing value for %s%s", prefix, name))
 	}
 
 	// Try to parse the argument as a value of the appropriate type.
-	return opt.tryAppendValue(value)
+	return parser.invalidValueError(prefix+name, value, stream, index, opt.tryAppendValue(value))
+}
+
+// Returns true if the setting is enabled for the parser or for any of its ancestor parsers.
+func (parser *ArgParser) inheritedSetting(setting func(*ArgParser) bool) bool {
+	for p := parser; p != nil; p = p.parent {
+		if setting(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expands a unique prefix of a long-form flag or option name to the full name if abbreviations are
+// enabled. Returns the name unchanged if it's an exact match or isn't a prefix of any name. Returns
+// an error listing the candidates if the prefix is ambiguous.
+func (parser *ArgParser) expandLongName(name string) (string, error) {
+	if !parser.inheritedSetting(func(p *ArgParser) bool { return p.AbbreviatedOptions }) {
+		return name, nil
+	}
+	if _, found := parser.lookupOption(name); found {
+		return name, nil
+	}
+
+	// Candidates are indexed by target so that multiple aliases of a single option only count once.
+	matches := make([]string, 0)
+	targets := make(map[string]bool)
+	addCandidate := func(candidate string, target string) {
+		if strings.HasPrefix(candidate, name) && !targets[target] {
+			targets[target] = true
+			matches = append(matches, candidate)
+		}
+	}
+
+	for _, opt := range parser.visibleOptions() {
+		for _, alias := range opt.aliases {
+			if len([]rune(alias)) > 1 {
+				addCandidate(alias, opt.aliases[0])
+				if opt.Negatable && opt.kind == "flag" {
+					addCandidate("no-"+alias, "no-"+opt.aliases[0])
+				}
+			}
+		}
+	}
+	if _, found := parser.options["help"]; !found {
+		addCandidate("help", "help")
+	}
+	if _, found := parser.options["version"]; !found && parser.Version != "" {
+		addCandidate("version", "version")
+	}
+
+	for _, match := range matches {
+		if match == name {
+			return name, nil
+		}
+	}
+	if len(matches) > 1 {
+		for i, match := range matches {
+			matches[i] = "--" + match
+		}
+		return "", fmt.Errorf("--%s is ambiguous, could be %s", name, strings.Join(matches, ", "))
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	return name, nil
+}
+
+// Expands a unique prefix of a command name to the full name if abbreviations are enabled. If
+// builtins is true, the automatic 'help' and 'completion' commands are also candidates. Returns the
+// name unchanged if it's an exact match or isn't a prefix of any name. Returns an error listing the
+// candidates if the prefix is ambiguous.
+func (parser *ArgParser) expandCommandName(name string, builtins bool) (string, error) {
+	if name == "" || !parser.inheritedSetting(func(p *ArgParser) bool { return p.AbbreviatedCommands }) {
+		return name, nil
+	}
+	if _, found := parser.commands[name]; found {
+		return name, nil
+	}
+
+	matches := make([]string, 0)
+	for _, cmdParser := range parser.commandList {
+		for _, alias := range cmdParser.aliases {
+			if strings.HasPrefix(alias, name) {
+				matches = append(matches, alias)
+				break
+			}
+		}
+	}
+	if builtins {
+		for _, builtin := range []string{"help", "completion"} {
+			if _, found := parser.commands[builtin]; !found && isBuiltinCommand(parser, builtin) && strings.HasPrefix(builtin, name) {
+				matches = append(matches, builtin)
+			}
+		}
+	}
+
+	for _, match := range matches {
+		if match == name {
+			return name, nil
+		}
+	}
+	if len(matches) > 1 {
+		return "", fmt.Errorf("'%s' is ambiguous, could be %s", name, strings.Join(matches, ", "))
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	return name, nil
+}
+
+// Returns the flag or option registered under name, either on the parser itself or as a persistent
+// flag or option on one of its ancestor parsers. The nearest registration wins.
+func (parser *ArgParser) lookupOption(name string) (*Option, bool) {
+	if opt, found := parser.options[name]; found {
+		return opt, true
+	}
+	for p := parser.parent; p != nil; p = p.parent {
+		if opt, found := p.options[name]; found && opt.Persistent {
+			return opt, true
+		}
+	}
+	return nil, false
+}
+
+// Returns the parser's own flags and options followed by the persistent flags and options it
+// inherits from its ancestor parsers, skipping any whose primary name is shadowed.
+func (parser *ArgParser) visibleOptions() []*Option {
+	options := append([]*Option{}, parser.optionList...)
+	for p := parser.parent; p != nil; p = p.parent {
+		for _, opt := range p.optionList {
+			if found, _ := parser.lookupOption(opt.aliases[0]); opt.Persistent && found == opt {
+				options = append(options, opt)
+			}
+		}
+	}
+	return options
+}
+
+// Returns the negatable flag matching a long-form name of the form no-<name>.
+func (parser *ArgParser) negatedFlag(name string) (*Option, bool) {
+	name, hasPrefix := strings.CutPrefix(name, "no-")
+	if !hasPrefix || len([]rune(name)) < 2 {
+		return nil, false
+	}
+	opt, found := parser.lookupOption(name)
+	if !found || opt.kind != "flag" || !opt.Negatable {
+		return nil, false
+	}
+	return opt, true
 }
 
 // -------------------------------------------------------------------------
@@ -539,15 +1443,41 @@ func (parser *ArgParser) parseEqualsOption(prefix string, arg string) error {
 // -------------------------------------------------------------------------
 
 // exitWithHelptext prints the parser's help text, then exits.
-func (parser *ArgParser) exitWithHelptext() {
-	fmt.Println(strings.TrimSpace(parser.Helptext))
-	os.Exit(0)
+func (parser *ArgParser) exitWithHelptext() error {
+	fmt.Fprintln(parser.stdout(), parser.helptext())
+	return parser.exit(ErrHelpRequested)
 }
 
 // exitWithVersion prints the parser's version string, then exits.
-func (parser *ArgParser) exitWithVersion() {
-	fmt.Println(strings.TrimSpace(parser.Version))
+func (parser *ArgParser) exitWithVersion() error {
+	fmt.Fprintln(parser.stdout(), strings.TrimSpace(parser.Version))
+	return parser.exit(ErrVersionRequested)
+}
+
+// Exits with a zero exit code unless the parser's NoExit setting is enabled. Returns the sentinel
+// error for Parse() to return if the parser doesn't exit.
+func (parser *ArgParser) exit(sentinel error) error {
+	if parser.inheritedSetting(func(p *ArgParser) bool { return p.NoExit }) {
+		return sentinel
+	}
+	for p := parser; p != nil; p = p.parent {
+		if p.Exit != nil {
+			p.Exit(0)
+			return sentinel
+		}
+	}
 	os.Exit(0)
+	return sentinel
+}
+
+// Returns the parser's standard output writer.
+func (parser *ArgParser) stdout() io.Writer {
+	for p := parser; p != nil; p = p.parent {
+		if p.Stdout != nil {
+			return p.Stdout
+		}
+	}
+	return os.Stdout
 }
 
 // String returns a string representation of the parser instance for debugging.
@@ -567,12 +1497,21 @@ func (parser *ArgParser) String() string {
 			switch opt.kind {
 			case "flag":
 				values = fmt.Sprintf("%v", opt.count)
-			case "string":
+				if opt.negated {
+					values += " (negated)"
+				}
+			case "string", "custom":
 				values = fmt.Sprintf("(%v) %v", opt.stringFallback, opt.stringValues)
 			case "int":
 				values = fmt.Sprintf("(%v) %v", opt.intFallback, opt.intValues)
 			case "float":
 				values = fmt.Sprintf("(%v) %v", opt.floatFallback, opt.floatValues)
+			case "duration":
+				values = fmt.Sprintf("(%v) %v", opt.durationFallback, opt.durationValues)
+			case "map":
+				values = fmt.Sprintf("%v", opt.stringValues)
+			case "time":
+				values = fmt.Sprintf("(%v) %v", opt.timeFallback, opt.timeValues)
 			}
 			lines = append(lines, fmt.Sprintf("  %s [%s]: %s", name, opt.kind, values))
 		}
@@ -1,6 +1,9 @@
 package argo
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 /* -------- */
 /*  Flags.  */
@@ -78,6 +81,71 @@ func TestBoolMultiShortform(t *testing.T) {
 	}
 }
 
+func TestNegatableFlagUnset(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("color").Negatable = true
+	parser.Parse([]string{"ignored"})
+	if parser.FlagState("color") != FlagUnset {
+		t.Fail()
+	}
+	if parser.Found("color") != false {
+		t.Fail()
+	}
+}
+
+func TestNegatableFlagPositive(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("color c").Negatable = true
+	parser.Parse([]string{"ignored", "-c"})
+	if parser.FlagState("color") != FlagTrue {
+		t.Fail()
+	}
+	if parser.Found("color") != true {
+		t.Fail()
+	}
+}
+
+func TestNegatableFlagNegative(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("color").Negatable = true
+	parser.Parse([]string{"ignored", "--no-color"})
+	if parser.FlagState("color") != FlagFalse {
+		t.Fail()
+	}
+	if parser.Found("color") != false {
+		t.Fail()
+	}
+	if parser.Count("color") != 1 {
+		t.Fail()
+	}
+}
+
+func TestNegatableFlagLastWins(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("color").Negatable = true
+	parser.Parse([]string{"ignored", "--no-color", "--color", "--no-color"})
+	if parser.FlagState("color") != FlagFalse {
+		t.Fail()
+	}
+}
+
+func TestNegatedFormRequiresNegatable(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("color")
+	if err := parser.Parse([]string{"ignored", "--no-color"}); err == nil {
+		t.Fail()
+	}
+}
+
+func TestNegatedFormRejectsValue(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("color").Negatable = true
+	err := parser.Parse([]string{"ignored", "--no-color=true"})
+	if err == nil || err.Error() != "invalid value assignment for flag --no-color" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 /* ----------------- */
 /*  String options.  */
 /* ----------------- */
@@ -420,6 +488,406 @@ func TestFloatListShortform(t *testing.T) {
 	}
 }
 
+/* ------------------- */
+/*  Duration options.  */
+/* ------------------- */
+
+func TestDurationOptionEmpty(t *testing.T) {
+	parser := NewParser()
+	parser.NewDurationOption("opt", 5*time.Second)
+	parser.Parse([]string{"ignored"})
+	if parser.DurationValue("opt") != 5*time.Second {
+		t.Fail()
+	}
+	if parser.Found("opt") != false {
+		t.Fail()
+	}
+}
+
+func TestDurationOptionGoSyntax(t *testing.T) {
+	parser := NewParser()
+	parser.NewDurationOption("opt o", 0)
+	parser.Parse([]string{"ignored", "-o", "1h30m"})
+	if parser.DurationValue("opt") != 90*time.Minute {
+		t.Fail()
+	}
+	if parser.Count("opt") != 1 {
+		t.Fail()
+	}
+}
+
+func TestDurationOptionSeconds(t *testing.T) {
+	parser := NewParser()
+	parser.NewDurationOption("opt", 0)
+	parser.Parse([]string{"ignored", "--opt", "1.5"})
+	if parser.DurationValue("opt") != 1500*time.Millisecond {
+		t.Fail()
+	}
+}
+
+func TestDurationList(t *testing.T) {
+	parser := NewParser()
+	parser.NewDurationOption("opt", 0)
+	parser.Parse([]string{"ignored", "--opt", "1s", "--opt=2m"})
+	if len(parser.DurationValues("opt")) != 2 {
+		t.Fail()
+	}
+	if parser.DurationValues("opt")[1] != 2*time.Minute {
+		t.Fail()
+	}
+}
+
+func TestDurationOptionInvalid(t *testing.T) {
+	parser := NewParser()
+	parser.NewDurationOption("opt", 0)
+	err := parser.Parse([]string{"ignored", "--opt", "soon"})
+	if err == nil || err.Error() != "cannot parse 'soon' as a duration" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDurationOptionOutOfRange(t *testing.T) {
+	for _, arg := range []string{"inf", "-Inf", "NaN", "1e20", "-1e20"} {
+		parser := NewParser()
+		parser.NewDurationOption("opt", 0)
+		err := parser.Parse([]string{"ignored", "--opt", arg})
+		if err == nil || err.Error() != "cannot parse '"+arg+"' as a duration" {
+			t.Errorf("unexpected error for '%s': %v", arg, err)
+		}
+	}
+}
+
+/* --------------- */
+/*  Time options.  */
+/* --------------- */
+
+func TestTimeOptionEmpty(t *testing.T) {
+	fallback := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	parser := NewParser()
+	parser.NewTimeOption("opt", fallback)
+	parser.Parse([]string{"ignored"})
+	if !parser.TimeValue("opt").Equal(fallback) {
+		t.Fail()
+	}
+	if parser.Found("opt") != false {
+		t.Fail()
+	}
+}
+
+func TestTimeOptionRFC3339(t *testing.T) {
+	parser := NewParser()
+	parser.NewTimeOption("opt", time.Time{})
+	parser.Parse([]string{"ignored", "--opt", "2021-02-03T04:05:06+01:00"})
+	expected := time.Date(2021, 2, 3, 3, 5, 6, 0, time.UTC)
+	if !parser.TimeValue("opt").Equal(expected) {
+		t.Fail()
+	}
+}
+
+func TestTimeOptionDate(t *testing.T) {
+	parser := NewParser()
+	parser.NewTimeOption("opt o", time.Time{})
+	parser.Parse([]string{"ignored", "-o", "2021-02-03"})
+	expected := time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC)
+	if !parser.TimeValue("opt").Equal(expected) {
+		t.Fail()
+	}
+}
+
+func TestTimeOptionUnix(t *testing.T) {
+	parser := NewParser()
+	parser.NewTimeOption("opt", time.Time{})
+	parser.Parse([]string{"ignored", "--opt", "1000000000", "--opt", "@0"})
+	if parser.TimeValues("opt")[0].Unix() != 1000000000 {
+		t.Fail()
+	}
+	if parser.TimeValue("opt").Unix() != 0 {
+		t.Fail()
+	}
+}
+
+func TestTimeOptionInvalid(t *testing.T) {
+	parser := NewParser()
+	parser.NewTimeOption("opt", time.Time{})
+	err := parser.Parse([]string{"ignored", "--opt", "yesterday"})
+	if err == nil || err.Error() != "cannot parse 'yesterday' as a time" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+/* ----------------- */
+/*  Choice options.  */
+/* ----------------- */
+
+func TestChoiceOptionEmpty(t *testing.T) {
+	parser := NewParser()
+	parser.NewChoiceOption("format", "json", "json", "yaml", "table")
+	parser.Parse([]string{"ignored"})
+	if parser.StringValue("format") != "json" {
+		t.Fail()
+	}
+}
+
+func TestChoiceOptionValid(t *testing.T) {
+	parser := NewParser()
+	parser.NewChoiceOption("format f", "json", "json", "yaml", "table")
+	if err := parser.Parse([]string{"ignored", "-f", "yaml"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.StringValue("format") != "yaml" {
+		t.Fail()
+	}
+}
+
+func TestChoiceOptionInvalid(t *testing.T) {
+	parser := NewParser()
+	parser.NewChoiceOption("format", "json", "json", "yaml", "table")
+	err := parser.Parse([]string{"ignored", "--format", "jsn"})
+	if err == nil || err.Error() != "invalid value 'jsn', must be one of: json, yaml, table (did you mean 'json'?)" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestChoiceOptionInvalidNoSuggestion(t *testing.T) {
+	parser := NewParser()
+	parser.NewChoiceOption("format", "json", "json", "yaml", "table")
+	err := parser.Parse([]string{"ignored", "--format=xml-document"})
+	if err == nil || err.Error() != "invalid value 'xml-document', must be one of: json, yaml, table" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestChoiceOptionCaseInsensitive(t *testing.T) {
+	parser := NewParser()
+	parser.NewChoiceOption("format", "json", "json", "yaml").CaseInsensitive = true
+	if err := parser.Parse([]string{"ignored", "--format", "YAML"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.StringValue("format") != "yaml" {
+		t.Fail()
+	}
+	err := parser.Parse([]string{"ignored", "--format", "YML"})
+	if err == nil || err.Error() != "invalid value 'YML', must be one of: json, yaml (did you mean 'yaml'?)" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestChoiceOptionCaseSensitive(t *testing.T) {
+	parser := NewParser()
+	parser.NewChoiceOption("format", "json", "json", "yaml")
+	if err := parser.Parse([]string{"ignored", "--format", "JSON"}); err == nil {
+		t.Fail()
+	}
+}
+
+/* -------------- */
+/*  Map options.  */
+/* -------------- */
+
+func TestMapOptionEmpty(t *testing.T) {
+	parser := NewParser()
+	parser.NewMapOption("label")
+	parser.Parse([]string{"ignored"})
+	if len(parser.MapValue("label")) != 0 {
+		t.Fail()
+	}
+	if parser.Found("label") != false {
+		t.Fail()
+	}
+}
+
+func TestMapOption(t *testing.T) {
+	parser := NewParser()
+	parser.NewMapOption("label l")
+	parser.Parse([]string{"ignored", "--label", "env=prod", "-l", "team=infra", "--label=expr=a=b"})
+	labels := parser.MapValue("label")
+	if len(labels) != 3 || labels["env"] != "prod" || labels["team"] != "infra" || labels["expr"] != "a=b" {
+		t.Fail()
+	}
+	if parser.Count("label") != 3 || len(parser.StringValues("label")) != 3 {
+		t.Fail()
+	}
+}
+
+func TestMapOptionLastWins(t *testing.T) {
+	parser := NewParser()
+	parser.NewMapOption("label")
+	parser.Parse([]string{"ignored", "--label", "env=dev", "--label", "env=prod"})
+	if parser.MapValue("label")["env"] != "prod" {
+		t.Fail()
+	}
+}
+
+func TestMapOptionRejectDuplicates(t *testing.T) {
+	parser := NewParser()
+	parser.NewMapOption("label").RejectDuplicateKeys = true
+	err := parser.Parse([]string{"ignored", "--label", "env=dev", "--label", "env=prod"})
+	if err == nil || err.Error() != "duplicate key 'env' in 'env=prod'" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMapOptionSeparator(t *testing.T) {
+	parser := NewParser()
+	parser.NewMapOption("header").Separator = ":"
+	parser.Parse([]string{"ignored", "--header", "Accept:text/html"})
+	if parser.MapValue("header")["Accept"] != "text/html" {
+		t.Fail()
+	}
+}
+
+func TestMapOptionInvalid(t *testing.T) {
+	parser := NewParser()
+	parser.NewMapOption("label")
+	err := parser.Parse([]string{"ignored", "--label", "env"})
+	if err == nil || err.Error() != "cannot parse 'env' as a key=value pair" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestIntMapOption(t *testing.T) {
+	parser := NewParser()
+	parser.NewIntMapOption("limit")
+	parser.Parse([]string{"ignored", "--limit", "cpu=2", "--limit", "mem=0x10"})
+	limits := parser.IntMapValue("limit")
+	if limits["cpu"] != 2 || limits["mem"] != 16 {
+		t.Fail()
+	}
+	err := parser.Parse([]string{"ignored", "--limit", "cpu=two"})
+	if err == nil || err.Error() != "cannot parse 'two' in 'cpu=two' as an integer" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFloatMapOption(t *testing.T) {
+	parser := NewParser()
+	parser.NewFloatMapOption("weight")
+	parser.Parse([]string{"ignored", "--weight", "a=0.5"})
+	if parser.FloatMapValue("weight")["a"] != 0.5 {
+		t.Fail()
+	}
+}
+
+/* ------------------------ */
+/*  Delimiter-split lists.  */
+/* ------------------------ */
+
+func TestDelimitedStringList(t *testing.T) {
+	parser := NewParser()
+	parser.NewStringOption("tags", "").Delimiter = ","
+	parser.Parse([]string{"ignored", "--tags", "a,b", "--tags", "c"})
+	values := parser.StringValues("tags")
+	if len(values) != 3 || values[0] != "a" || values[1] != "b" || values[2] != "c" {
+		t.Fail()
+	}
+	if parser.StringValue("tags") != "c" {
+		t.Fail()
+	}
+	if parser.Count("tags") != 2 {
+		t.Fail()
+	}
+}
+
+func TestDelimitedStringListEscapes(t *testing.T) {
+	parser := NewParser()
+	parser.NewStringOption("tags", "").Delimiter = ","
+	parser.Parse([]string{"ignored", `--tags=a\,b,c\\,d\e`})
+	values := parser.StringValues("tags")
+	if len(values) != 3 || values[0] != "a,b" || values[1] != `c\` || values[2] != `d\e` {
+		t.Errorf("unexpected values: %q", values)
+	}
+}
+
+func TestDelimitedIntList(t *testing.T) {
+	parser := NewParser()
+	parser.NewIntOption("ports p", 0).Delimiter = ","
+	parser.Parse([]string{"ignored", "-p", "80,443", "-p", "8080"})
+	values := parser.IntValues("ports")
+	if len(values) != 3 || values[0] != 80 || values[1] != 443 || values[2] != 8080 {
+		t.Fail()
+	}
+	err := parser.Parse([]string{"ignored", "-p", "80,x"})
+	if err == nil || err.Error() != "cannot parse 'x' as an integer" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDelimitedFloatList(t *testing.T) {
+	parser := NewParser()
+	parser.NewFloatOption("weights", 0).Delimiter = ":"
+	parser.Parse([]string{"ignored", "--weights", "0.5:1.5"})
+	values := parser.FloatValues("weights")
+	if len(values) != 2 || values[0] != 0.5 || values[1] != 1.5 {
+		t.Fail()
+	}
+}
+
+/* ------------------ */
+/*  Optional values.  */
+/* ------------------ */
+
+func newOptionalValueTestParser() *ArgParser {
+	parser := NewParser()
+	opt := parser.NewChoiceOption("color c", "auto", "always", "never", "auto")
+	opt.OptionalValue = true
+	opt.ImplicitValue = "always"
+	parser.NewFlag("verbose v")
+	return parser
+}
+
+func TestOptionalValueMissing(t *testing.T) {
+	parser := newOptionalValueTestParser()
+	parser.Parse([]string{"ignored"})
+	if parser.StringValue("color") != "auto" {
+		t.Fail()
+	}
+}
+
+func TestOptionalValueImplicit(t *testing.T) {
+	parser := newOptionalValueTestParser()
+	parser.Parse([]string{"ignored", "--color", "arg"})
+	if parser.StringValue("color") != "always" {
+		t.Fail()
+	}
+	if len(parser.Args) != 1 || parser.Args[0] != "arg" {
+		t.Fail()
+	}
+}
+
+func TestOptionalValueEquals(t *testing.T) {
+	parser := newOptionalValueTestParser()
+	parser.Parse([]string{"ignored", "--color=never"})
+	if parser.StringValue("color") != "never" {
+		t.Fail()
+	}
+}
+
+func TestOptionalValueShortform(t *testing.T) {
+	parser := newOptionalValueTestParser()
+	parser.Parse([]string{"ignored", "-vc", "arg"})
+	if parser.StringValue("color") != "always" || !parser.Found("verbose") {
+		t.Fail()
+	}
+	if len(parser.Args) != 1 {
+		t.Fail()
+	}
+}
+
+func TestOptionalValueShortformAttached(t *testing.T) {
+	parser := newOptionalValueTestParser()
+	parser.Parse([]string{"ignored", "-cnever"})
+	if parser.StringValue("color") != "never" {
+		t.Fail()
+	}
+}
+
+func TestOptionalValueInvalid(t *testing.T) {
+	parser := newOptionalValueTestParser()
+	if err := parser.Parse([]string{"ignored", "--color=sometimes"}); err == nil {
+		t.Fail()
+	}
+}
+
 /* -------------------------------- */
 /*  Multiple option types at once.  */
 /* -------------------------------- */
@@ -555,6 +1023,79 @@ func TestMultiOptionsShortform(t *testing.T) {
 	}
 }
 
+/* ------------------------------ */
+/*  Abbreviated long-form names.  */
+/* ------------------------------ */
+
+func newAbbreviationTestParser() *ArgParser {
+	parser := NewParser()
+	parser.AbbreviatedOptions = true
+	parser.Version = "1.0"
+	parser.NewFlag("verbose")
+	parser.NewFlag("color colour").Negatable = true
+	parser.NewStringOption("output", "default")
+	parser.NewStringOption("out", "default")
+	return parser
+}
+
+func TestAbbreviatedFlag(t *testing.T) {
+	parser := newAbbreviationTestParser()
+	if err := parser.Parse([]string{"ignored", "--verb", "--col"}); err != nil {
+		t.Fatal(err)
+	}
+	if !parser.Found("verbose") || !parser.Found("color") {
+		t.Fail()
+	}
+}
+
+func TestAbbreviatedNegatedFlag(t *testing.T) {
+	parser := newAbbreviationTestParser()
+	if err := parser.Parse([]string{"ignored", "--no-c"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.FlagState("color") != FlagFalse {
+		t.Fail()
+	}
+}
+
+func TestAbbreviatedOption(t *testing.T) {
+	parser := newAbbreviationTestParser()
+	if err := parser.Parse([]string{"ignored", "--outp", "foo", "--out=bar"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.StringValue("output") != "foo" || parser.StringValue("out") != "bar" {
+		t.Fail()
+	}
+	parser = newAbbreviationTestParser()
+	if err := parser.Parse([]string{"ignored", "--outp=foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.StringValue("output") != "foo" {
+		t.Fail()
+	}
+}
+
+func TestAbbreviatedNameAmbiguous(t *testing.T) {
+	parser := newAbbreviationTestParser()
+	err := parser.Parse([]string{"ignored", "--ver"})
+	if err == nil || err.Error() != "--ver is ambiguous, could be --verbose, --version" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	parser = newAbbreviationTestParser()
+	err = parser.Parse([]string{"ignored", "--o=foo"})
+	if err == nil || err.Error() != "--o is ambiguous, could be --output, --out" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAbbreviatedNameDisabled(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("verbose")
+	if err := parser.Parse([]string{"ignored", "--verb"}); err == nil {
+		t.Fail()
+	}
+}
+
 /* ------------------------------- */
 /*  Condensed short-form options.  */
 /* ------------------------------- */
@@ -580,6 +1121,80 @@ func TestCondensedOptions(t *testing.T) {
 	}
 }
 
+func TestCondensedOptionsAttachedValues(t *testing.T) {
+	parser := NewParser()
+	parser.AttachedShortValues = true
+	parser.NewFlag("bool b")
+	parser.NewStringOption("string s", "default")
+	parser.NewIntOption("int i", 101)
+	parser.Parse([]string{"ignored", "-bsvalue", "-i202"})
+	if parser.Found("bool") != true {
+		t.Fail()
+	}
+	if parser.StringValue("string") != "value" {
+		t.Fail()
+	}
+	if parser.IntValue("int") != 202 {
+		t.Fail()
+	}
+}
+
+func TestCondensedOptionsAttachedValuesTrailing(t *testing.T) {
+	parser := NewParser()
+	parser.AttachedShortValues = true
+	parser.NewFlag("bool b")
+	parser.NewStringOption("string s", "default")
+	parser.Parse([]string{"ignored", "-bs", "value", "arg"})
+	if parser.Found("bool") != true || parser.StringValue("string") != "value" {
+		t.Fail()
+	}
+	if len(parser.Args) != 1 || parser.Args[0] != "arg" {
+		t.Fail()
+	}
+}
+
+func TestCondensedOptionsAttachedValuesEquals(t *testing.T) {
+	parser := NewParser()
+	parser.AttachedShortValues = true
+	parser.NewStringOption("include I", "")
+	parser.NewMapOption("define D")
+	parser.Parse([]string{"ignored", "-I=/usr/include", "-I/usr/local/include", "-Dkey=value"})
+	values := parser.StringValues("include")
+	if len(values) != 2 || values[0] != "/usr/include" || values[1] != "/usr/local/include" {
+		t.Errorf("unexpected values: %q", values)
+	}
+	if parser.MapValue("define")["key"] != "value" {
+		t.Fail()
+	}
+	if err := parser.Parse([]string{"ignored", "-I="}); err == nil || err.Error() != "missing value for -I" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCondensedOptionsAttachedValuesFlagAssignment(t *testing.T) {
+	parser := NewParser()
+	parser.AttachedShortValues = true
+	parser.NewFlag("a")
+	parser.NewFlag("b")
+	for _, arg := range []string{"-a=x", "-ba=x"} {
+		err := parser.Parse([]string{"ignored", arg})
+		if err == nil || err.Error() != "invalid value assignment for flag -a" {
+			t.Errorf("unexpected error for %s: %v", arg, err)
+		}
+	}
+}
+
+func TestCondensedOptionsAttachedValuesInherited(t *testing.T) {
+	parser := NewParser()
+	parser.AttachedShortValues = true
+	cmdParser := parser.NewCommand("cmd")
+	cmdParser.NewIntOption("num n", 0)
+	parser.Parse([]string{"ignored", "cmd", "-n5"})
+	if cmdParser.IntValue("num") != 5 {
+		t.Fail()
+	}
+}
+
 /* ----------------------- */
 /*  Positional arguments.  */
 /* ----------------------- */
@@ -704,3 +1319,124 @@ func TestCommandWithOptions(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestCommandAbbreviated(t *testing.T) {
+	parser := NewParser()
+	parser.AbbreviatedCommands = true
+	buildParser := parser.NewCommand("build")
+	parser.NewCommand("bench")
+	testParser := buildParser.NewCommand("test")
+	if err := parser.Parse([]string{"ignored", "bu", "te", "arg"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.FoundCommandName != "build" || buildParser.FoundCommandName != "test" {
+		t.Fail()
+	}
+	if len(testParser.Args) != 1 || testParser.Args[0] != "arg" {
+		t.Fail()
+	}
+}
+
+func TestCommandAbbreviatedAmbiguous(t *testing.T) {
+	parser := NewParser()
+	parser.AbbreviatedCommands = true
+	parser.NewCommand("build")
+	parser.NewCommand("bench")
+	err := parser.Parse([]string{"ignored", "b"})
+	if err == nil || err.Error() != "'b' is ambiguous, could be build, bench" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err = parser.Parse([]string{"ignored", "help", "b"})
+	if err == nil || err.Error() != "help: 'b' is ambiguous, could be build, bench" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCommandAbbreviatedNoMatch(t *testing.T) {
+	parser := NewParser()
+	parser.AbbreviatedCommands = true
+	parser.NewCommand("build")
+	if err := parser.Parse([]string{"ignored", "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.FoundCommandName != "" || len(parser.Args) != 1 {
+		t.Fail()
+	}
+}
+
+func TestCommandAbbreviatedEmptyName(t *testing.T) {
+	parser := NewParser()
+	parser.AbbreviatedCommands = true
+	parser.EnableHelpCommand = true
+	parser.NewCommand("build")
+	parser.NewCommand("bench")
+	if err := parser.Parse([]string{"ignored", ""}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.FoundCommandName != "" || len(parser.Args) != 1 || parser.Args[0] != "" {
+		t.Fail()
+	}
+	err := parser.Parse([]string{"ignored", "help", ""})
+	if err == nil || err.Error() != "help: '' is not a recognised command name" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCommandAbbreviationDisabled(t *testing.T) {
+	parser := NewParser()
+	parser.NewCommand("build")
+	parser.Parse([]string{"ignored", "bu"})
+	if parser.FoundCommandName != "" || len(parser.Args) != 1 {
+		t.Fail()
+	}
+}
+
+func TestCommandPersistentOptions(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("verbose v").Persistent = true
+	parser.NewStringOption("config", "default").Persistent = true
+	buildParser := parser.NewCommand("build")
+	testParser := buildParser.NewCommand("test")
+	err := parser.Parse([]string{"ignored", "build", "-v", "test", "--config=app.ini", "--verbose"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parser.Count("verbose") != 2 || parser.StringValue("config") != "app.ini" {
+		t.Fail()
+	}
+	if !buildParser.Found("verbose") || testParser.Count("v") != 2 {
+		t.Fail()
+	}
+	if testParser.StringValue("config") != "app.ini" {
+		t.Fail()
+	}
+}
+
+func TestCommandPersistentOptionShadowed(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("verbose v").Persistent = true
+	cmdParser := parser.NewCommand("build")
+	cmdParser.NewIntOption("version v", 0)
+	if err := parser.Parse([]string{"ignored", "build", "-v", "2", "--verbose"}); err != nil {
+		t.Fatal(err)
+	}
+	if cmdParser.IntValue("v") != 2 || parser.Count("verbose") != 1 {
+		t.Fail()
+	}
+}
+
+func TestCommandNonPersistentOption(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("verbose")
+	cmdParser := parser.NewCommand("build")
+	err := parser.Parse([]string{"ignored", "build", "--verbose"})
+	if err == nil || err.Error() != "--verbose is not a recognised flag or option name" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	cmdParser.Found("verbose")
+}
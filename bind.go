@@ -0,0 +1,345 @@
+package argo
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/* ---------------------------- */
+/*  ArgParser: struct binding.  */
+/* ---------------------------- */
+
+// A fieldBinding links a struct field to a registered option or to the parser's positional
+// arguments.
+type fieldBinding struct {
+	field reflect.Value
+	opt   *Option
+
+	// For bool fields, the value used if the flag isn't set.
+	fallback bool
+
+	// For slice fields, the default tag parsed as the option's values if the option isn't found.
+	sliceDefault string
+}
+
+// Bind registers flags, options, and commands on the parser for the fields of the struct pointed to
+// by target. After a successful call to Parse(), the struct's fields are filled with the parsed
+// values.
+//
+// Field types map to flag and option types as follows:
+//
+//	bool                    flag
+//	string, []string        string-valued option
+//	int, []int              integer-valued option
+//	float64, []float64      float-valued option
+//	time.Duration           duration-valued option, also []time.Duration
+//	time.Time               time-valued option, also []time.Time
+//	map[string]string       map-valued option, also map[string]int and map[string]float64
+//	struct                  command
+//
+// Fields whose pointer type implements Value, flag.Value, or encoding.TextUnmarshaler are
+// registered as custom-typed options -- see NewValueOption().
+//
+// A field's initial value is used as the option's default value unless a default tag is specified.
+// Bool fields are set to true if the flag is found, or to false if a negatable flag is negated. If
+// the flag isn't set, bool fields receive their default value.
+// Slice fields receive the option's full list of values if the option is found, or the values
+// parsed from the default tag if the option isn't found and the tag is specified. Map fields don't
+// support default tags.
+//
+// Fields are configured using struct tags:
+//
+//	name:"output"           the long-form name, defaults to the field name in kebab-case
+//	aliases:"o out"         additional space-separated aliases and shortcuts
+//	default:"out.txt"       the default value, defaults to the field's initial value
+//	help:"Output file."     the description for generated helptext
+//	env:"APP_OUTPUT"        the bound environment variable
+//	required:"true"         marks the option as required
+//	choices:"json yaml"     space-separated choices for string fields -- see NewChoiceOption()
+//	delimiter:","           splits each argument into multiple values -- see Option.Delimiter
+//	negatable:"true"        accepts a --no-<name> form for bool fields -- see Option.Negatable
+//	implicit:"always"       makes the value optional with this implicit value -- see OptionalValue
+//	persistent:"true"       accepts the option after nested commands -- see Option.Persistent
+//
+// A field tagged `args:"true"` with type []string receives the parser's positional arguments. A
+// field tagged `name:"-"` is ignored, as are unexported fields. Nested struct fields are registered
+// as commands and bound recursively.
+//
+// Returns an error if target is not a pointer to a struct or if a field has an unsupported type
+// or an invalid default value.
+func (parser *ArgParser) Bind(target any) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("argo: Bind() requires a pointer to a struct, found %T", target)
+	}
+	return parser.bindStruct(value.Elem())
+}
+
+// Registers flags, options, and commands for the fields of a struct value.
+func (parser *ArgParser) bindStruct(value reflect.Value) error {
+	structType := value.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		fieldType := structType.Field(i)
+		field := value.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		name := fieldType.Tag.Get("name")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = kebabCase(fieldType.Name)
+		}
+		if aliases := fieldType.Tag.Get("aliases"); aliases != "" {
+			name += " " + aliases
+		}
+
+		if fieldType.Tag.Get("args") == "true" {
+			if field.Type() != reflect.TypeOf([]string{}) {
+				return fmt.Errorf("argo: field %s: positional arguments require type []string", fieldType.Name)
+			}
+			parser.bindings = append(parser.bindings, fieldBinding{field: field})
+			continue
+		}
+
+		if _, isValue := asValue(field.Addr().Interface()); field.Kind() == reflect.Struct && !isValue {
+			cmdParser := parser.NewCommand(name)
+			cmdParser.Description = fieldType.Tag.Get("help")
+			if err := cmdParser.bindStruct(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		opt, err := parser.bindField(name, field, fieldType.Tag.Get("default"), fieldType.Tag.Get("choices"))
+		if err != nil {
+			return fmt.Errorf("argo: field %s: %w", fieldType.Name, err)
+		}
+		opt.Description = fieldType.Tag.Get("help")
+		opt.Env = fieldType.Tag.Get("env")
+		opt.Required = fieldType.Tag.Get("required") == "true"
+		opt.Delimiter = fieldType.Tag.Get("delimiter")
+		opt.Negatable = fieldType.Tag.Get("negatable") == "true"
+		opt.Persistent = fieldType.Tag.Get("persistent") == "true"
+		if implicit, found := fieldType.Tag.Lookup("implicit"); found {
+			opt.OptionalValue = true
+			opt.ImplicitValue = implicit
+		}
+		binding := fieldBinding{field: field, opt: opt}
+		if ptr, ok := field.Addr().Interface().(*bool); ok {
+			binding.fallback = *ptr
+		}
+		if field.Kind() == reflect.Slice {
+			binding.sliceDefault = fieldType.Tag.Get("default")
+		}
+		parser.bindings = append(parser.bindings, binding)
+	}
+
+	return nil
+}
+
+// Registers a flag or option for a struct field. The fallback and choices parameters are the
+// field's default and choices tags.
+func (parser *ArgParser) bindField(name string, field reflect.Value, fallback string, choices string) (*Option, error) {
+	if field.Kind() == reflect.Map && fallback != "" {
+		return nil, fmt.Errorf("default values are not supported for map fields")
+	}
+
+	switch ptr := field.Addr().Interface().(type) {
+	case *bool:
+		if fallback != "" {
+			value, err := strconv.ParseBool(fallback)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse default '%s' as a boolean", fallback)
+			}
+			*ptr = value
+		}
+		return parser.NewFlag(name), nil
+
+	case *string, *[]string:
+		if s, ok := ptr.(*string); ok && fallback == "" {
+			fallback = *s
+		}
+		if choices != "" {
+			return parser.NewChoiceOption(name, fallback, strings.Split(choices, " ")...), nil
+		}
+		return parser.NewStringOption(name, fallback), nil
+
+	case *int, *[]int:
+		value := 0
+		if fallback != "" {
+			parsed, err := strconv.ParseInt(fallback, 0, 0)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse default '%s' as an integer", fallback)
+			}
+			value = int(parsed)
+		} else if i, ok := ptr.(*int); ok {
+			value = *i
+		}
+		return parser.NewIntOption(name, value), nil
+
+	case *float64, *[]float64:
+		value := 0.0
+		if fallback != "" {
+			parsed, err := strconv.ParseFloat(fallback, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse default '%s' as a float", fallback)
+			}
+			value = parsed
+		} else if f, ok := ptr.(*float64); ok {
+			value = *f
+		}
+		return parser.NewFloatOption(name, value), nil
+
+	case *time.Duration, *[]time.Duration:
+		var value time.Duration
+		if fallback != "" {
+			parsed, err := parseDuration(fallback)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse default '%s' as a duration", fallback)
+			}
+			value = parsed
+		} else if d, ok := ptr.(*time.Duration); ok {
+			value = *d
+		}
+		return parser.NewDurationOption(name, value), nil
+
+	case *time.Time, *[]time.Time:
+		var value time.Time
+		if fallback != "" {
+			parsed, err := parseTime(fallback)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse default '%s' as a time", fallback)
+			}
+			value = parsed
+		} else if tm, ok := ptr.(*time.Time); ok {
+			value = *tm
+		}
+		return parser.NewTimeOption(name, value), nil
+
+	case *map[string]string:
+		return parser.NewMapOption(name), nil
+
+	case *map[string]int:
+		return parser.NewIntMapOption(name), nil
+
+	case *map[string]float64:
+		return parser.NewFloatMapOption(name), nil
+	}
+
+	// Fields implementing Value, flag.Value, or encoding.TextUnmarshaler receive values directly.
+	if _, ok := asValue(field.Addr().Interface()); ok {
+		opt := parser.NewValueOption(name, field.Addr().Interface())
+		if fallback != "" {
+			if err := opt.value.Set(fallback); err != nil {
+				return nil, fmt.Errorf("cannot parse default '%s' as a %s value: %w", fallback, opt.value.Type(), err)
+			}
+			opt.stringFallback = fallback
+		}
+		return opt, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", field.Type())
+}
+
+// Fills the parser's bound struct fields with parsed values.
+func (parser *ArgParser) fillBindings() {
+	for _, binding := range parser.bindings {
+		opt := binding.opt
+
+		if opt == nil {
+			binding.field.Set(reflect.ValueOf(append([]string{}, parser.Args...)))
+			continue
+		}
+
+		// Slice fields receive their default values from a scratch copy of the option.
+		if opt.count == 0 && binding.sliceDefault != "" {
+			scratch := *opt
+			scratch.reset()
+			if scratch.tryAppendValue(binding.sliceDefault) == nil {
+				scratch.count = 1
+				opt = &scratch
+			}
+		}
+
+		switch ptr := binding.field.Addr().Interface().(type) {
+		case *bool:
+			switch opt.FlagState() {
+			case FlagTrue:
+				*ptr = true
+			case FlagFalse:
+				*ptr = false
+			case FlagUnset:
+				*ptr = binding.fallback
+			}
+		case *string:
+			*ptr = parser.StringValue(opt.aliases[0])
+		case *int:
+			*ptr = parser.IntValue(opt.aliases[0])
+		case *float64:
+			*ptr = parser.FloatValue(opt.aliases[0])
+		case *time.Duration:
+			*ptr = opt.durationValue()
+		case *time.Time:
+			*ptr = opt.timeValue()
+		case *[]string:
+			if opt.count > 0 {
+				*ptr = append([]string{}, opt.stringValues...)
+			}
+		case *[]int:
+			if opt.count > 0 {
+				*ptr = append([]int{}, opt.intValues...)
+			}
+		case *[]float64:
+			if opt.count > 0 {
+				*ptr = append([]float64{}, opt.floatValues...)
+			}
+		case *[]time.Duration:
+			if opt.count > 0 {
+				*ptr = append([]time.Duration{}, opt.durationValues...)
+			}
+		case *[]time.Time:
+			if opt.count > 0 {
+				*ptr = append([]time.Time{}, opt.timeValues...)
+			}
+		case *map[string]string:
+			if opt.count > 0 {
+				*ptr = opt.stringMap
+			}
+		case *map[string]int:
+			if opt.count > 0 {
+				*ptr = opt.intMap
+			}
+		case *map[string]float64:
+			if opt.count > 0 {
+				*ptr = opt.floatMap
+			}
+		}
+	}
+}
+
+// Converts a Go field name to kebab-case, e.g. "OutputDir" to "output-dir".
+func kebabCase(name string) string {
+	runes := []rune(name)
+	var builder strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Insert a dash at the start of each word, treating runs of capitals as acronyms,
+			// e.g. "HTTPServer" becomes "http-server".
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				builder.WriteRune('-')
+			}
+			builder.WriteRune(unicode.ToLower(r))
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,255 @@
+package argo
+
+import "testing"
+
+/* ----------------- */
+/*  Struct binding.  */
+/* ----------------- */
+
+type bindTestBuild struct {
+	Release bool     `aliases:"r" help:"Build in release mode."`
+	Target  string   `default:"linux"`
+	Files   []string `args:"true"`
+}
+
+type bindTestConfig struct {
+	Verbose   bool          `aliases:"v"`
+	OutputDir string        `aliases:"o" help:"The output directory."`
+	Jobs      int           `default:"4"`
+	Ratio     float64       `name:"ratio"`
+	Tags      []string      `name:"tag" aliases:"t"`
+	Ports     []int         `name:"port"`
+	Weights   []float64     `name:"weight"`
+	Ignored   string        `name:"-"`
+	Build     bindTestBuild `aliases:"b" help:"Build the project."`
+	internal  int
+}
+
+func TestBindValues(t *testing.T) {
+	config := bindTestConfig{OutputDir: "out", Ratio: 0.5}
+	parser := NewParser()
+	if err := parser.Bind(&config); err != nil {
+		t.Fatal(err)
+	}
+	err := parser.Parse([]string{
+		"ignored",
+		"-v",
+		"--jobs", "8",
+		"-t", "a", "-t", "b",
+		"--port", "80",
+		"--weight", "1.5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Verbose != true {
+		t.Fail()
+	}
+	if config.OutputDir != "out" {
+		t.Fail()
+	}
+	if config.Jobs != 8 {
+		t.Fail()
+	}
+	if config.Ratio != 0.5 {
+		t.Fail()
+	}
+	if len(config.Tags) != 2 || config.Tags[1] != "b" {
+		t.Fail()
+	}
+	if len(config.Ports) != 1 || config.Ports[0] != 80 {
+		t.Fail()
+	}
+	if len(config.Weights) != 1 || config.Weights[0] != 1.5 {
+		t.Fail()
+	}
+	if config.Build.Target != "" {
+		t.Fail()
+	}
+}
+
+func TestBindCommand(t *testing.T) {
+	config := bindTestConfig{}
+	parser := NewParser()
+	if err := parser.Bind(&config); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored", "b", "-r", "foo", "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.FoundCommandName != "b" {
+		t.Fail()
+	}
+	if config.Build.Release != true {
+		t.Fail()
+	}
+	if config.Build.Target != "linux" {
+		t.Fail()
+	}
+	if len(config.Build.Files) != 2 || config.Build.Files[0] != "foo" {
+		t.Fail()
+	}
+	if parser.commands["build"].Description != "Build the project." {
+		t.Fail()
+	}
+}
+
+func TestBindRegistration(t *testing.T) {
+	parser := NewParser()
+	if err := parser.Bind(&bindTestConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := parser.options["output-dir"]; !found {
+		t.Fail()
+	}
+	if _, found := parser.options["ignored"]; found {
+		t.Fail()
+	}
+	if _, found := parser.options["internal"]; found {
+		t.Fail()
+	}
+	if parser.options["o"].Description != "The output directory." {
+		t.Fail()
+	}
+}
+
+func TestBindEnvAndRequired(t *testing.T) {
+	t.Setenv("ARGO_TEST_NAME", "env")
+	config := struct {
+		Name  string `env:"ARGO_TEST_NAME"`
+		Token string `required:"true"`
+	}{}
+	parser := NewParser()
+	if err := parser.Bind(&config); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored"}); err == nil {
+		t.Fail()
+	}
+	if err := parser.Parse([]string{"ignored", "--token", "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "env" || config.Token != "abc" {
+		t.Fail()
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	if err := NewParser().Bind(bindTestConfig{}); err == nil {
+		t.Fail()
+	}
+	if err := NewParser().Bind(&struct{ Count uint }{}); err == nil {
+		t.Fail()
+	}
+	if err := NewParser().Bind(&struct {
+		Count int `default:"abc"`
+	}{}); err == nil {
+		t.Fail()
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	if kebabCase("OutputDir") != "output-dir" {
+		t.Fail()
+	}
+	if kebabCase("HTTPServer") != "http-server" {
+		t.Fail()
+	}
+	if kebabCase("ID") != "id" {
+		t.Fail()
+	}
+}
+
+func TestBindMapFields(t *testing.T) {
+	config := struct {
+		Label  map[string]string
+		Limit  map[string]int
+		Weight map[string]float64
+	}{}
+	parser := NewParser()
+	if err := parser.Bind(&config); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored", "--label", "a=b", "--limit", "c=1"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.Label["a"] != "b" || config.Limit["c"] != 1 || config.Weight != nil {
+		t.Fail()
+	}
+}
+
+func TestBindNegatableFlag(t *testing.T) {
+	config := struct {
+		Color bool `negatable:"true"`
+	}{Color: true}
+	parser := NewParser()
+	if err := parser.Bind(&config); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored", "--no-color"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.Color != false {
+		t.Fail()
+	}
+}
+
+func TestBindFlagDefault(t *testing.T) {
+	config := struct {
+		Debug bool `default:"true" negatable:"true"`
+	}{}
+	parser := NewParser()
+	if err := parser.Bind(&config); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.Debug != true {
+		t.Fail()
+	}
+	if err := parser.Parse([]string{"ignored", "--no-debug"}); err != nil || config.Debug != false {
+		t.Fail()
+	}
+	if err := parser.Parse([]string{"ignored"}); err != nil || config.Debug != true {
+		t.Fail()
+	}
+
+	if err := NewParser().Bind(&struct {
+		Debug bool `default:"yes"`
+	}{}); err == nil {
+		t.Fail()
+	}
+}
+
+func TestBindSliceDefault(t *testing.T) {
+	config := struct {
+		Tags  []string `default:"a,b" delimiter:","`
+		Ports []int    `default:"80"`
+	}{}
+	parser := NewParser()
+	if err := parser.Bind(&config); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Tags) != 2 || config.Tags[0] != "a" || config.Tags[1] != "b" {
+		t.Errorf("unexpected tags: %q", config.Tags)
+	}
+	if len(config.Ports) != 1 || config.Ports[0] != 80 {
+		t.Errorf("unexpected ports: %v", config.Ports)
+	}
+	if err := parser.Parse([]string{"ignored", "--tags", "c", "--ports", "8080"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Tags) != 1 || config.Tags[0] != "c" || len(config.Ports) != 1 || config.Ports[0] != 8080 {
+		t.Fail()
+	}
+
+	if err := NewParser().Bind(&struct {
+		Labels map[string]string `default:"a=b"`
+	}{}); err == nil {
+		t.Fail()
+	}
+}
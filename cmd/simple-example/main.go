@@ -10,12 +10,13 @@ import (
 func main() {
 	// Create a new ArgParser instance.
 	parser := argo.NewParser()
-	parser.Helptext = "Usage: example..."
+	parser.Description = "A simple example application."
 	parser.Version = "1.2.3"
 
-	// Register a flag and a string-valued option.
-	parser.NewFlag("foo f")
-	parser.NewStringOption("bar b", "fallback")
+	// Register a flag and a string-valued option. The descriptions are used to generate the
+	// application's --help text.
+	parser.NewFlag("foo f").Description = "An example flag."
+	parser.NewStringOption("bar b", "fallback").Description = "An example option."
 
 	// Parse the command line arguments.
 	if err := parser.ParseOsArgs(); err != nil {
@@ -0,0 +1,626 @@
+package argo
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+/* -------------------------------------- */
+/*  ArgParser: shell completion scripts.  */
+/* -------------------------------------- */
+
+// A completion candidate, i.e. a flag, option, or command name, with its description.
+type candidate struct {
+	name        string
+	description string
+}
+
+// A node in the command tree, i.e. a parser instance with its path of canonical command names.
+type completionNode struct {
+	path   string
+	parser *ArgParser
+}
+
+// CompletionScript returns a shell completion script for the parser's command line interface.
+// Supported shells are "bash", "zsh", and "fish". The script is always generated for the root
+// parser's command tree, even if this method is called on a command parser.
+//
+// If any flag, option, or command in the tree has a registered completer, the script calls the
+// application at completion time to generate candidates -- see the Completer type. Otherwise the
+// script is static.
+//
+// The script is registered for the application name -- see the Name field.
+func (parser *ArgParser) CompletionScript(shell string) (string, error) {
+	root := parser
+	for root.parent != nil {
+		root = root.parent
+	}
+
+	dynamic := root.hasCompleters()
+
+	switch shell {
+	case "bash":
+		if dynamic {
+			return root.dynamicBashCompletionScript(), nil
+		}
+		return root.bashCompletionScript(), nil
+	case "zsh":
+		if dynamic {
+			return root.dynamicZshCompletionScript(), nil
+		}
+		return root.zshCompletionScript(), nil
+	case "fish":
+		if dynamic {
+			return root.dynamicFishCompletionScript(), nil
+		}
+		return root.fishCompletionScript(), nil
+	}
+	return "", fmt.Errorf("unsupported shell '%s', expected one of bash, zsh, or fish", shell)
+}
+
+// exitWithCompletionScript prints the completion script for the shell named by the stream's current
+// argument, then exits.
+func (parser *ArgParser) exitWithCompletionScript(stream *argstream) error {
+	shell, _ := stream.current()
+	script, err := parser.CompletionScript(shell)
+	if err != nil {
+		return parser.commandArgumentError("completion", stream, "completion: "+err.Error())
+	}
+	fmt.Fprint(parser.stdout(), script)
+	return parser.exit(ErrCompletionRequested)
+}
+
+// Returns the parser's flag and option candidates, including inherited persistent flags and options
+// and the automatic --help and --version flags.
+func (parser *ArgParser) optionCandidates() []candidate {
+	candidates := make([]candidate, 0)
+	for _, opt := range parser.visibleOptions() {
+		for _, alias := range opt.aliases {
+			candidates = append(candidates, candidate{optionPrefix(alias) + alias, opt.Description})
+		}
+		if opt.Negatable && opt.kind == "flag" {
+			for _, alias := range opt.aliases {
+				if len([]rune(alias)) > 1 {
+					candidates = append(candidates, candidate{"--no-" + alias, opt.Description})
+				}
+			}
+		}
+	}
+	if _, found := parser.options["help"]; !found {
+		candidates = append(candidates, candidate{"--help", "Print the helptext and exit."})
+		if _, found := parser.options["h"]; !found {
+			candidates = append(candidates, candidate{"-h", "Print the helptext and exit."})
+		}
+	}
+	if _, found := parser.options["version"]; !found && parser.Version != "" {
+		candidates = append(candidates, candidate{"--version", "Print the version number and exit."})
+		if _, found := parser.options["v"]; !found {
+			candidates = append(candidates, candidate{"-v", "Print the version number and exit."})
+		}
+	}
+	return candidates
+}
+
+// Returns the parser's command candidates, including the automatic 'help' and 'completion' commands.
+func (parser *ArgParser) commandCandidates() []candidate {
+	candidates := make([]candidate, 0)
+	for _, cmdParser := range parser.commandList {
+		for _, alias := range cmdParser.aliases {
+			candidates = append(candidates, candidate{alias, cmdParser.Description})
+		}
+	}
+	if _, found := parser.commands["help"]; !found && parser.EnableHelpCommand {
+		candidates = append(candidates, candidate{"help", "Print the helptext for a command."})
+	}
+	if _, found := parser.commands["completion"]; !found && parser.EnableCompletionCommand {
+		candidates = append(candidates, candidate{"completion", "Print a shell completion script."})
+	}
+	return candidates
+}
+
+// Returns the dash prefix for a flag or option alias, i.e. "-" for shortcuts and "--" for long-form
+// names.
+func optionPrefix(alias string) string {
+	if len([]rune(alias)) == 1 {
+		return "-"
+	}
+	return "--"
+}
+
+// Returns the nodes of the parser's command tree in depth-first order, starting with the parser
+// itself.
+func (parser *ArgParser) completionNodes() []completionNode {
+	nodes := []completionNode{{"", parser}}
+	for _, cmdParser := range parser.commandList {
+		for _, node := range cmdParser.completionNodes() {
+			path := strings.TrimSpace(cmdParser.aliases[0] + " " + node.path)
+			nodes = append(nodes, completionNode{path, node.parser})
+		}
+	}
+	return nodes
+}
+
+// Returns the application name as a valid shell function identifier.
+func (parser *ArgParser) completionFuncName() string {
+	return "_" + strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, parser.commandPath())
+}
+
+// Writes shell case patterns that advance the command path when a command name is found. The sep
+// parameter separates alternative patterns.
+func writeCommandPathCases(builder *strings.Builder, nodes []completionNode, quote func(string) string, sep string, format string) {
+	for _, node := range nodes {
+		for _, cmdParser := range node.parser.commandList {
+			patterns := make([]string, 0, len(cmdParser.aliases))
+			for _, alias := range cmdParser.aliases {
+				patterns = append(patterns, quote(node.path+":"+alias))
+			}
+			next := strings.TrimSpace(node.path + " " + cmdParser.aliases[0])
+			builder.WriteString(fmt.Sprintf(format, strings.Join(patterns, sep), quote(next)))
+		}
+	}
+}
+
+// Returns a string wrapped in single quotes for use in a POSIX shell script.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// Returns a string wrapped in single quotes for use in a fish script.
+func fishQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+}
+
+// Returns a bash completion script for the parser's command tree.
+func (parser *ArgParser) bashCompletionScript() string {
+	name := parser.commandPath()
+	funcName := parser.completionFuncName()
+	nodes := parser.completionNodes()
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# bash completion for %s\n", name))
+	builder.WriteString(funcName + "() {\n")
+	builder.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	builder.WriteString("    local cmdpath=\"\" words=\"\" i\n")
+	builder.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	builder.WriteString("        case \"${cmdpath}:${COMP_WORDS[i]}\" in\n")
+	writeCommandPathCases(&builder, nodes, shellQuote, "|", "            %s) cmdpath=%s ;;\n")
+	builder.WriteString("        esac\n")
+	builder.WriteString("    done\n")
+	builder.WriteString("    case \"${cmdpath}\" in\n")
+	for _, node := range nodes {
+		words := make([]string, 0)
+		for _, c := range append(node.parser.optionCandidates(), node.parser.commandCandidates()...) {
+			words = append(words, c.name)
+		}
+		builder.WriteString(fmt.Sprintf("        %s) words=%s ;;\n", shellQuote(node.path), shellQuote(strings.Join(words, " "))))
+	}
+	builder.WriteString("    esac\n")
+	builder.WriteString("    COMPREPLY=($(compgen -W \"${words}\" -- \"${cur}\"))\n")
+	builder.WriteString("}\n")
+	builder.WriteString(fmt.Sprintf("complete -o default -F %s %s\n", funcName, shellQuote(name)))
+	return builder.String()
+}
+
+// Returns a zsh completion script for the parser's command tree.
+func (parser *ArgParser) zshCompletionScript() string {
+	name := parser.commandPath()
+	funcName := parser.completionFuncName()
+	nodes := parser.completionNodes()
+
+	// Escapes a candidate for use in a _describe specification.
+	spec := func(c candidate) string {
+		value := strings.ReplaceAll(c.name, ":", `\:`)
+		if c.description != "" {
+			value += ":" + c.description
+		}
+		return shellQuote(value)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("#compdef %s\n", name))
+	builder.WriteString(funcName + "() {\n")
+	builder.WriteString("    local cmdpath=\"\" i\n")
+	builder.WriteString("    local -a candidates\n")
+	builder.WriteString("    for ((i = 2; i < CURRENT; i++)); do\n")
+	builder.WriteString("        case \"${cmdpath}:${words[i]}\" in\n")
+	writeCommandPathCases(&builder, nodes, shellQuote, "|", "            %s) cmdpath=%s ;;\n")
+	builder.WriteString("        esac\n")
+	builder.WriteString("    done\n")
+	builder.WriteString("    case \"${cmdpath}\" in\n")
+	for _, node := range nodes {
+		specs := make([]string, 0)
+		for _, c := range append(node.parser.optionCandidates(), node.parser.commandCandidates()...) {
+			specs = append(specs, spec(c))
+		}
+		builder.WriteString(fmt.Sprintf("        %s) candidates=(%s) ;;\n", shellQuote(node.path), strings.Join(specs, " ")))
+	}
+	builder.WriteString("    esac\n")
+	builder.WriteString("    _describe 'values' candidates || _files\n")
+	builder.WriteString("}\n")
+	builder.WriteString(fmt.Sprintf("compdef %s %s\n", funcName, shellQuote(name)))
+	return builder.String()
+}
+
+// Returns a fish completion script for the parser's command tree.
+func (parser *ArgParser) fishCompletionScript() string {
+	name := parser.commandPath()
+	funcName := parser.completionFuncName() + "_in_cmdpath"
+	nodes := parser.completionNodes()
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# fish completion for %s\n", name))
+	builder.WriteString("function " + funcName + "\n")
+	builder.WriteString("    set -l cmdpath \"\"\n")
+	builder.WriteString("    for token in (commandline -opc)[2..-1]\n")
+	builder.WriteString("        switch \"$cmdpath:$token\"\n")
+	writeCommandPathCases(&builder, nodes, fishQuote, " ", "            case %s\n                set cmdpath %s\n")
+	builder.WriteString("        end\n")
+	builder.WriteString("    end\n")
+	builder.WriteString("    test \"$cmdpath\" = \"$argv[1]\"\n")
+	builder.WriteString("end\n")
+
+	for _, node := range nodes {
+		prefix := fmt.Sprintf("complete -c %s -n %s", fishQuote(name), fishQuote(funcName+" "+shellQuote(node.path)))
+		for _, c := range node.parser.optionCandidates() {
+			line := prefix
+			if strings.HasPrefix(c.name, "--") {
+				line += " -l " + fishQuote(c.name[2:])
+			} else {
+				line += " -s " + fishQuote(c.name[1:])
+			}
+			if c.description != "" {
+				line += " -d " + fishQuote(c.description)
+			}
+			builder.WriteString(line + "\n")
+		}
+		for _, c := range node.parser.commandCandidates() {
+			line := prefix + " -a " + fishQuote(c.name)
+			if c.description != "" {
+				line += " -d " + fishQuote(c.description)
+			}
+			builder.WriteString(line + "\n")
+		}
+	}
+
+	return builder.String()
+}
+
+/* -------------------------------- */
+/*  ArgParser: dynamic completion.  */
+/* -------------------------------- */
+
+// A CompletionDirective tells the shell completion script how to handle a list of completion
+// candidates. Directives can be combined using the bitwise OR operator.
+type CompletionDirective int
+
+// CompleteDefault tells the shell to use the candidates and append a space.
+const CompleteDefault CompletionDirective = 0
+
+const (
+	// CompleteNoSpace tells the shell not to append a space after the completed word.
+	CompleteNoSpace CompletionDirective = 1 << iota
+
+	// CompleteFiles tells the shell to ignore the candidates and complete file names instead.
+	CompleteFiles
+
+	// CompleteDirectories tells the shell to ignore the candidates and complete directory names
+	// instead.
+	CompleteDirectories
+)
+
+// A Completer is a function that returns completion candidates for an option value or a positional
+// argument. The partial parameter is the partially-typed word being completed. Candidates that
+// don't begin with partial are discarded. A candidate can include a description for display by the
+// shell, separated from the candidate by a tab character.
+//
+// Completers are called by the dynamic completion protocol. Choice options without a registered
+// completer complete their choices automatically. If any flag, option, or command in the parser's
+// command tree has a registered completer or is a choice option, CompletionScript() generates a
+// script that calls the application with the hidden '__complete' command and the partial command
+// line as arguments, e.g.
+//
+//	$ app __complete build --target ''
+//
+// The application prints the completion candidates one per line followed by a final line
+// containing the completion directive, e.g. ':0', then exits.
+type Completer func(partial string) ([]string, CompletionDirective)
+
+// Returns true if the parser or any of its command parsers has a registered completer. Choice
+// options have an implicit completer.
+func (parser *ArgParser) hasCompleters() bool {
+	if len(parser.ArgCompleters) > 0 {
+		return true
+	}
+	for _, opt := range parser.optionList {
+		if opt.Completer != nil || len(opt.choices) > 0 {
+			return true
+		}
+	}
+	for _, cmdParser := range parser.commandList {
+		if cmdParser.hasCompleters() {
+			return true
+		}
+	}
+	return false
+}
+
+// exitWithCompletions prints the completion candidates for a partial command line, then exits.
+// The final element of words is the word being completed.
+func (parser *ArgParser) exitWithCompletions(words []string) error {
+	candidates, directive := parser.completions(words)
+	for _, candidate := range candidates {
+		fmt.Fprintln(parser.stdout(), candidate)
+	}
+	fmt.Fprintf(parser.stdout(), ":%d\n", directive)
+	return parser.exit(ErrCompletionRequested)
+}
+
+// Returns the completion candidates and directive for a partial command line. The final element
+// of words is the word being completed.
+func (parser *ArgParser) completions(words []string) ([]string, CompletionDirective) {
+	partial := ""
+	if len(words) > 0 {
+		partial = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	current := parser
+	argCount := 0
+	optionParsing := true
+	pending := make([]*Option, 0)
+	builtin := ""
+
+	// Replay the preceding words to find the active parser and the cursor's context.
+	for _, word := range words {
+		if optionParsing && len(pending) == 0 && strings.HasPrefix(word, "-") && word != "-" {
+			if word == "--" {
+				optionParsing = false
+				continue
+			}
+			if strings.HasPrefix(word, "--") {
+				name, _ := current.expandLongName(word[2:])
+				if opt, found := current.lookupOption(name); found && opt.takesArgument() {
+					pending = append(pending, opt)
+				}
+				continue
+			}
+			if !unicode.IsDigit([]rune(word)[1]) {
+				attached := current.inheritedSetting(func(p *ArgParser) bool { return p.AttachedShortValues })
+				for i, char := range word[1:] {
+					opt, found := current.lookupOption(string(char))
+					if !found || opt.kind == "flag" {
+						continue
+					}
+					// An attached value, an equals sign, or an optional value ends the cluster.
+					rest := word[1+i+len(string(char)):]
+					if opt.OptionalValue || strings.HasPrefix(rest, "=") || (attached && rest != "") {
+						break
+					}
+					pending = append(pending, opt)
+				}
+				continue
+			}
+		}
+
+		if len(pending) > 0 {
+			pending = pending[1:]
+			continue
+		}
+
+		if argCount == 0 && builtin == "" {
+			name, _ := current.expandCommandName(word, true)
+			if cmdParser, found := current.commands[name]; found {
+				current = cmdParser
+				continue
+			}
+			if isBuiltinCommand(current, name) {
+				builtin = name
+				continue
+			}
+		}
+
+		argCount += 1
+	}
+
+	// Are we completing an option value?
+	if len(pending) > 0 {
+		return completeOptionValue(pending[0], partial, "")
+	}
+
+	// Are we completing an option value of the form --name=value?
+	if optionParsing && strings.HasPrefix(partial, "-") && strings.Contains(partial, "=") {
+		name, value, _ := strings.Cut(partial, "=")
+		if opt, found := current.lookupOption(strings.TrimLeft(name, "-")); found && opt.kind != "flag" {
+			return completeOptionValue(opt, value, name+"=")
+		}
+		return nil, CompleteDefault
+	}
+
+	// Are we completing an option name?
+	if optionParsing && strings.HasPrefix(partial, "-") {
+		return filterCandidates(current.optionCandidates(), partial), CompleteDefault
+	}
+
+	// Are we completing the argument of an automatic 'help' or 'completion' command?
+	if builtin == "help" {
+		if argCount > 0 {
+			return nil, CompleteDefault
+		}
+		return filterCandidates(current.commandCandidates(), partial), CompleteDefault
+	}
+	if builtin == "completion" {
+		if argCount > 0 {
+			return nil, CompleteDefault
+		}
+		shells := []candidate{{"bash", ""}, {"zsh", ""}, {"fish", ""}}
+		return filterCandidates(shells, partial), CompleteDefault
+	}
+
+	// We're completing a command name or a positional argument.
+	results := make([]string, 0)
+	if argCount == 0 {
+		results = append(results, filterCandidates(current.commandCandidates(), partial)...)
+	}
+
+	if len(current.ArgCompleters) > 0 {
+		index := argCount
+		if index >= len(current.ArgCompleters) {
+			index = len(current.ArgCompleters) - 1
+		}
+		if completer := current.ArgCompleters[index]; completer != nil {
+			values, directive := completer(partial)
+			return append(results, filterValues(values, partial, "")...), directive
+		}
+	}
+
+	if len(results) == 0 {
+		return results, CompleteFiles
+	}
+	return results, CompleteDefault
+}
+
+// Returns a bash completion script that calls the application to generate candidates.
+func (parser *ArgParser) dynamicBashCompletionScript() string {
+	name := parser.commandPath()
+	funcName := parser.completionFuncName()
+
+	lines := []string{
+		fmt.Sprintf("# bash completion for %s", name),
+		funcName + "() {",
+		"    local cur=\"${COMP_WORDS[COMP_CWORD]}\"",
+		"    local output directive line",
+		"    output=$(\"${COMP_WORDS[0]}\" __complete \"${COMP_WORDS[@]:1:COMP_CWORD}\" 2>/dev/null) || return",
+		"    directive=\"${output##*:}\"",
+		"    output=\"${output%:*}\"",
+		"    COMPREPLY=()",
+		fmt.Sprintf("    if (( directive & %d )); then", CompleteFiles),
+		"        COMPREPLY=($(compgen -f -- \"${cur}\"))",
+		fmt.Sprintf("    elif (( directive & %d )); then", CompleteDirectories),
+		"        COMPREPLY=($(compgen -d -- \"${cur}\"))",
+		"    else",
+		"        while IFS='' read -r line; do",
+		"            [[ -n \"${line}\" ]] && COMPREPLY+=(\"${line%%$'\\t'*}\")",
+		"        done <<< \"${output}\"",
+		fmt.Sprintf("        (( directive & %d )) && compopt -o nospace", CompleteNoSpace),
+		"    fi",
+		"}",
+		fmt.Sprintf("complete -o default -F %s %s", funcName, shellQuote(name)),
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Returns a zsh completion script that calls the application to generate candidates.
+func (parser *ArgParser) dynamicZshCompletionScript() string {
+	name := parser.commandPath()
+	funcName := parser.completionFuncName()
+
+	lines := []string{
+		fmt.Sprintf("#compdef %s", name),
+		funcName + "() {",
+		"    local -a lines specs",
+		"    local directive line name",
+		"    lines=(\"${(@f)$(\"${words[1]}\" __complete \"${(@)words[2,CURRENT]}\" 2>/dev/null)}\")",
+		"    directive=\"${lines[-1]#:}\"",
+		fmt.Sprintf("    if (( directive & %d )); then", CompleteFiles),
+		"        _files",
+		"        return",
+		"    fi",
+		fmt.Sprintf("    if (( directive & %d )); then", CompleteDirectories),
+		"        _files -/",
+		"        return",
+		"    fi",
+		"    for line in \"${(@)lines[1,-2]}\"; do",
+		"        name=\"${${line%%$'\\t'*}//:/\\:}\"",
+		"        if [[ \"${line}\" == *$'\\t'* ]]; then",
+		"            specs+=(\"${name}:${line#*$'\\t'}\")",
+		"        else",
+		"            specs+=(\"${name}\")",
+		"        fi",
+		"    done",
+		fmt.Sprintf("    if (( directive & %d )); then", CompleteNoSpace),
+		"        _describe 'values' specs -S ''",
+		"    else",
+		"        _describe 'values' specs",
+		"    fi",
+		"}",
+		fmt.Sprintf("compdef %s %s", funcName, shellQuote(name)),
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Returns a fish completion script that calls the application to generate candidates.
+func (parser *ArgParser) dynamicFishCompletionScript() string {
+	name := parser.commandPath()
+	funcName := parser.completionFuncName() + "_complete"
+
+	lines := []string{
+		fmt.Sprintf("# fish completion for %s", name),
+		"function " + funcName,
+		"    set -l tokens (commandline -opc)",
+		"    set -l current (commandline -ct)",
+		"    set -l output ($tokens[1] __complete $tokens[2..-1] \"$current\" 2>/dev/null)",
+		"    or return",
+		"    set -l directive (string replace -- ':' '' $output[-1])",
+		fmt.Sprintf("    if test (math \"bitand($directive, %d)\") -ne 0", CompleteFiles),
+		"        __fish_complete_path \"$current\"",
+		fmt.Sprintf("    else if test (math \"bitand($directive, %d)\") -ne 0", CompleteDirectories),
+		"        __fish_complete_directories \"$current\"",
+		"    else",
+		"        printf '%s\\n' $output[1..-2]",
+		"    end",
+		"end",
+		fmt.Sprintf("complete -c %s -f -a %s", fishQuote(name), fishQuote("("+funcName+")")),
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Returns true if word is the name of an enabled automatic command.
+func isBuiltinCommand(parser *ArgParser, word string) bool {
+	return (word == "help" && parser.EnableHelpCommand) || (word == "completion" && parser.EnableCompletionCommand)
+}
+
+// Returns the completion candidates for an option value. The prefix parameter is prepended to each
+// candidate, e.g. "--name=".
+func completeOptionValue(opt *Option, partial string, prefix string) ([]string, CompletionDirective) {
+	if opt.Completer == nil && len(opt.choices) > 0 {
+		return filterValues(opt.choices, partial, prefix), CompleteDefault
+	}
+	if opt.Completer == nil {
+		return nil, CompleteFiles
+	}
+	values, directive := opt.Completer(partial)
+	return filterValues(values, partial, prefix), directive
+}
+
+// Returns the candidates that begin with partial, formatted as completion protocol lines.
+func filterCandidates(candidates []candidate, partial string) []string {
+	results := make([]string, 0)
+	for _, c := range candidates {
+		if strings.HasPrefix(c.name, partial) {
+			if c.description != "" {
+				results = append(results, c.name+"\t"+c.description)
+			} else {
+				results = append(results, c.name)
+			}
+		}
+	}
+	return results
+}
+
+// Returns the values that begin with partial, with prefix prepended to each.
+func filterValues(values []string, partial string, prefix string) []string {
+	results := make([]string, 0)
+	for _, value := range values {
+		if strings.HasPrefix(value, partial) {
+			results = append(results, prefix+value)
+		}
+	}
+	return results
+}
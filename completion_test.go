@@ -0,0 +1,283 @@
+package argo
+
+import (
+	"strings"
+	"testing"
+)
+
+/* --------------------------- */
+/*  Shell completion scripts.  */
+/* --------------------------- */
+
+func newCompletionTestParser() *ArgParser {
+	parser := NewParser()
+	parser.Name = "app"
+	parser.EnableCompletionCommand = true
+	parser.NewFlag("verbose V").Description = "Print more output."
+	cmdParser := parser.NewCommand("build b")
+	cmdParser.Description = "Build the project."
+	cmdParser.NewFlag("release r")
+	cmdParser.NewCommand("docs")
+	return parser
+}
+
+func TestCompletionBash(t *testing.T) {
+	script, err := newCompletionTestParser().CompletionScript("bash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"':build'|':b') cmdpath='build' ;;",
+		"'build:docs') cmdpath='build docs' ;;",
+		"'') words='--verbose -V --help -h build b help completion' ;;",
+		"'build') words='--release -r --help -h docs help' ;;",
+		"complete -o default -F _app 'app'",
+	}
+	for _, line := range expected {
+		if !strings.Contains(script, line) {
+			t.Errorf("missing line: %s", line)
+		}
+	}
+}
+
+func TestCompletionZsh(t *testing.T) {
+	script, err := newCompletionTestParser().CompletionScript("zsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(script, "#compdef app\n") {
+		t.Fail()
+	}
+	if !strings.Contains(script, "'--verbose:Print more output.'") {
+		t.Fail()
+	}
+	if !strings.Contains(script, "'build:Build the project.'") {
+		t.Fail()
+	}
+}
+
+func TestCompletionFish(t *testing.T) {
+	script, err := newCompletionTestParser().CompletionScript("fish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(script, "case ':build' ':b'\n") {
+		t.Fail()
+	}
+	if !strings.Contains(script, `complete -c 'app' -n '_app_in_cmdpath \'\'' -l 'verbose' -d 'Print more output.'`) {
+		t.Fail()
+	}
+	if !strings.Contains(script, `complete -c 'app' -n '_app_in_cmdpath \'build\'' -s 'r'`) {
+		t.Fail()
+	}
+}
+
+func TestCompletionFromCommandParser(t *testing.T) {
+	parser := newCompletionTestParser()
+	fromRoot, _ := parser.CompletionScript("bash")
+	fromCommand, _ := parser.commands["build"].CompletionScript("bash")
+	if fromRoot != fromCommand {
+		t.Fail()
+	}
+}
+
+func TestCompletionUnsupportedShell(t *testing.T) {
+	_, err := newCompletionTestParser().CompletionScript("tcsh")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestCompletionCommandMissingArgument(t *testing.T) {
+	parser := newCompletionTestParser()
+	if err := parser.Parse([]string{"app", "completion"}); err == nil {
+		t.Fail()
+	}
+}
+
+/* --------------------- */
+/*  Dynamic completion.  */
+/* --------------------- */
+
+func newDynamicCompletionTestParser() *ArgParser {
+	parser := NewParser()
+	parser.Name = "app"
+	parser.NewFlag("verbose V").Description = "Print more output."
+	cmdParser := parser.NewCommand("build b")
+	cmdParser.NewFlag("release r")
+	cmdParser.NewStringOption("target t", "").Completer = func(partial string) ([]string, CompletionDirective) {
+		return []string{"linux", "darwin", "windows"}, CompleteDefault
+	}
+	cmdParser.NewStringOption("output o", "")
+	cmdParser.ArgCompleters = []Completer{
+		func(partial string) ([]string, CompletionDirective) {
+			return []string{"first"}, CompleteNoSpace
+		},
+		func(partial string) ([]string, CompletionDirective) {
+			return nil, CompleteDirectories
+		},
+	}
+	return parser
+}
+
+func TestCompletionsOptionNames(t *testing.T) {
+	candidates, directive := newDynamicCompletionTestParser().completions([]string{"--v"})
+	if len(candidates) != 1 || candidates[0] != "--verbose\tPrint more output." {
+		t.Fail()
+	}
+	if directive != CompleteDefault {
+		t.Fail()
+	}
+}
+
+func TestCompletionsCommandNames(t *testing.T) {
+	candidates, _ := newDynamicCompletionTestParser().completions([]string{"--verbose", "b"})
+	if len(candidates) != 2 || candidates[0] != "build" || candidates[1] != "b" {
+		t.Fail()
+	}
+}
+
+func TestCompletionsOptionValue(t *testing.T) {
+	candidates, directive := newDynamicCompletionTestParser().completions([]string{"build", "--target", "l"})
+	if len(candidates) != 1 || candidates[0] != "linux" {
+		t.Fail()
+	}
+	if directive != CompleteDefault {
+		t.Fail()
+	}
+}
+
+func TestCompletionsCondensedOptionValue(t *testing.T) {
+	candidates, _ := newDynamicCompletionTestParser().completions([]string{"b", "-rt", ""})
+	if len(candidates) != 3 {
+		t.Fail()
+	}
+}
+
+func TestCompletionsEqualsOptionValue(t *testing.T) {
+	candidates, _ := newDynamicCompletionTestParser().completions([]string{"build", "--target=d"})
+	if len(candidates) != 1 || candidates[0] != "--target=darwin" {
+		t.Fail()
+	}
+}
+
+func TestCompletionsOptionValueWithoutCompleter(t *testing.T) {
+	candidates, directive := newDynamicCompletionTestParser().completions([]string{"build", "-o", ""})
+	if len(candidates) != 0 {
+		t.Fail()
+	}
+	if directive != CompleteFiles {
+		t.Fail()
+	}
+}
+
+func TestCompletionsPositionalSlots(t *testing.T) {
+	parser := newDynamicCompletionTestParser()
+	candidates, directive := parser.completions([]string{"build", "--target", "linux", ""})
+	if len(candidates) != 1 || candidates[0] != "first" || directive != CompleteNoSpace {
+		t.Fail()
+	}
+	_, directive = parser.completions([]string{"build", "foo", ""})
+	if directive != CompleteDirectories {
+		t.Fail()
+	}
+	_, directive = parser.completions([]string{"build", "foo", "bar", ""})
+	if directive != CompleteDirectories {
+		t.Fail()
+	}
+}
+
+func TestCompletionsAfterDoubleDash(t *testing.T) {
+	candidates, directive := newDynamicCompletionTestParser().completions([]string{"build", "--", "-"})
+	if len(candidates) != 0 || directive != CompleteNoSpace {
+		t.Fail()
+	}
+}
+
+func TestCompletionsHelpCommand(t *testing.T) {
+	candidates, _ := newDynamicCompletionTestParser().completions([]string{"help", "bu"})
+	if len(candidates) != 1 || candidates[0] != "build" {
+		t.Fail()
+	}
+}
+
+func TestCompletionScriptDynamic(t *testing.T) {
+	script, _ := newDynamicCompletionTestParser().CompletionScript("bash")
+	if !strings.Contains(script, `__complete "${COMP_WORDS[@]:1:COMP_CWORD}"`) {
+		t.Fail()
+	}
+	script, _ = newCompletionTestParser().CompletionScript("bash")
+	if strings.Contains(script, "__complete") {
+		t.Fail()
+	}
+}
+
+func TestCompletionsChoiceOption(t *testing.T) {
+	parser := NewParser()
+	parser.NewChoiceOption("format f", "json", "json", "yaml", "table")
+	if !parser.hasCompleters() {
+		t.Fail()
+	}
+	candidates, _ := parser.completions([]string{"-f", "t"})
+	if len(candidates) != 1 || candidates[0] != "table" {
+		t.Fail()
+	}
+}
+
+func TestCompletionsOptionalValue(t *testing.T) {
+	parser := NewParser()
+	parser.NewChoiceOption("color c", "auto", "always", "never", "auto").OptionalValue = true
+	parser.NewCommand("build")
+	candidates, _ := parser.completions([]string{"--color", "b"})
+	if len(candidates) != 1 || candidates[0] != "build" {
+		t.Fail()
+	}
+	candidates, _ = parser.completions([]string{"--color=n"})
+	if len(candidates) != 1 || candidates[0] != "--color=never" {
+		t.Errorf("unexpected candidates: %q", candidates)
+	}
+}
+
+func TestCompletionsAttachedShortValue(t *testing.T) {
+	parser := newDynamicCompletionTestParser()
+	parser.AttachedShortValues = true
+	candidates, _ := parser.completions([]string{"build", "-tlinux", "-r", "--ta"})
+	if len(candidates) != 1 || candidates[0] != "--target" {
+		t.Errorf("unexpected candidates: %q", candidates)
+	}
+	candidates, _ = parser.completions([]string{"build", "-rt", "w"})
+	if len(candidates) != 1 || candidates[0] != "windows" {
+		t.Errorf("unexpected candidates: %q", candidates)
+	}
+}
+
+func TestCompletionsAbbreviatedOption(t *testing.T) {
+	parser := newDynamicCompletionTestParser()
+	parser.AbbreviatedOptions = true
+	candidates, _ := parser.completions([]string{"build", "--tar", "w"})
+	if len(candidates) != 1 || candidates[0] != "windows" {
+		t.Errorf("unexpected candidates: %q", candidates)
+	}
+}
+
+func TestCompletionsAbbreviatedCommand(t *testing.T) {
+	parser := newDynamicCompletionTestParser()
+	parser.AbbreviatedCommands = true
+	candidates, _ := parser.completions([]string{"bui", "--ta"})
+	if len(candidates) != 1 || candidates[0] != "--target" {
+		t.Errorf("unexpected candidates: %q", candidates)
+	}
+}
+
+func TestCompletionsPersistentOption(t *testing.T) {
+	parser := newDynamicCompletionTestParser()
+	parser.NewStringOption("config c", "").Persistent = true
+	candidates, _ := parser.completions([]string{"build", "--c"})
+	if len(candidates) != 1 || candidates[0] != "--config" {
+		t.Errorf("unexpected candidates: %q", candidates)
+	}
+	candidates, _ = parser.completions([]string{"build", "-c", "app.ini", "--t"})
+	if len(candidates) != 1 || candidates[0] != "--target" {
+		t.Errorf("unexpected candidates: %q", candidates)
+	}
+}
@@ -0,0 +1,374 @@
+package argo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/* -------------------------- */
+/*  ArgParser: config files.  */
+/* -------------------------- */
+
+// LoadConfigFile loads option values from a JSON or INI config file. Files with a .json extension
+// are parsed as JSON, all other files are parsed as INI.
+//
+// Values loaded from a config file are used for any flags and options that aren't found on the
+// command line or in a bound environment variable. This method can be called multiple times to
+// load layered config files -- values from later files override values from earlier files.
+//
+// In JSON files, keys map to option names, arrays map to multivalued options, and nested objects
+// map to map-valued options or commands, e.g.
+//
+//	{"verbose": true, "tags": ["a", "b"], "build": {"jobs": 4}}
+//
+// In INI files, keys map to option names and sections map to commands, with dots separating the
+// names of nested commands. Repeated keys map to multivalued options. Lines beginning with '#' or
+// ';' are comments, e.g.
+//
+//	verbose = true
+//	tags = a
+//	tags = b
+//
+//	[build]
+//	jobs = 4
+//
+// Flags accept any value accepted by strconv.ParseBool(). A bare INI key sets a flag to true.
+//
+// This method must be called after the parser's flags, options, and commands have been registered
+// and before Parse() is called. Returns an error naming the file and line if the file contains an
+// unrecognised option or command name or a value that can't be parsed as the option's type.
+func (parser *ArgParser) LoadConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		return parser.loadJSONConfig(path, data)
+	}
+	return parser.loadINIConfig(path, data)
+}
+
+// Stores a list of config values for an option after verifying that each value can be parsed as
+// the option's type. Values for custom-typed options can't be parsed without side effects so
+// they're verified when they're applied. The source parameter identifies the file and line for use
+// in error messages.
+func (opt *Option) setConfigValues(values []string, source string) error {
+	for _, value := range values {
+		if opt.kind == "flag" {
+			if _, err := strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("cannot parse '%s' as a boolean", value)
+			}
+			continue
+		}
+		if opt.kind == "custom" {
+			continue
+		}
+		scratch := &Option{
+			kind:                opt.kind,
+			choices:             opt.choices,
+			mapKind:             opt.mapKind,
+			CaseInsensitive:     opt.CaseInsensitive,
+			Separator:           opt.Separator,
+			RejectDuplicateKeys: opt.RejectDuplicateKeys,
+			Delimiter:           opt.Delimiter,
+		}
+		if err := scratch.tryAppendValue(value); err != nil {
+			return err
+		}
+	}
+	opt.configValues = values
+	opt.configSource = source
+	return nil
+}
+
+// Applies stored config values to any options not found on the command line or in the environment.
+// Options in the excluded set are skipped.
+func (parser *ArgParser) applyConfig(excluded map[*Option]bool) error {
+	for _, opt := range parser.optionList {
+		if opt.count > 0 || excluded[opt] || len(opt.configValues) == 0 {
+			continue
+		}
+
+		if opt.kind == "flag" {
+			value := opt.configValues[len(opt.configValues)-1]
+			found, err := strconv.ParseBool(value)
+			if err != nil {
+				return parser.invalidSourceValueError(opt, value, opt.configSource+": "+opt.aliases[0], err)
+			}
+			opt.setFlag(found)
+			continue
+		}
+
+		for _, value := range opt.configValues {
+			if err := opt.tryAppendValue(value); err != nil {
+				return parser.invalidSourceValueError(opt, value, opt.configSource+": "+opt.aliases[0], err)
+			}
+			opt.count += 1
+		}
+	}
+
+	return nil
+}
+
+// Loads config values from INI-formatted data.
+func (parser *ArgParser) loadINIConfig(filename string, data []byte) error {
+	// Values are collected per-parser so repeated keys can be combined into a single list.
+	type section struct {
+		parser *ArgParser
+		values map[string][]string
+		lines  map[string]int
+		keys   []string
+	}
+
+	newSection := func(parser *ArgParser) *section {
+		return &section{parser: parser, values: make(map[string][]string), lines: make(map[string]int)}
+	}
+
+	current := newSection(parser)
+	sections := []*section{current}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber += 1
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return fmt.Errorf("%s:%d: invalid section header '%s'", filename, lineNumber, line)
+			}
+			cmdParser := parser
+			for _, name := range strings.Split(strings.TrimSpace(line[1:len(line)-1]), ".") {
+				next, found := cmdParser.commands[strings.TrimSpace(name)]
+				if !found {
+					return fmt.Errorf("%s:%d: '%s' is not a recognised command name", filename, lineNumber, name)
+				}
+				cmdParser = next
+			}
+			current = newSection(cmdParser)
+			sections = append(sections, current)
+			continue
+		}
+
+		key, value, hasValue := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		value = unquote(strings.TrimSpace(value))
+		if !hasValue {
+			value = "true"
+		}
+
+		if _, found := current.values[key]; !found {
+			current.keys = append(current.keys, key)
+		}
+		current.values[key] = append(current.values[key], value)
+		current.lines[key] = lineNumber
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	for _, section := range sections {
+		for _, key := range section.keys {
+			opt, found := section.parser.options[key]
+			if !found {
+				return fmt.Errorf("%s:%d: '%s' is not a recognised flag or option name", filename, section.lines[key], key)
+			}
+			source := fmt.Sprintf("%s:%d", filename, section.lines[key])
+			if err := opt.setConfigValues(section.values[key], source); err != nil {
+				return fmt.Errorf("%s: %s: %w", source, key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Strips a single pair of matching quotes from around a string value.
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if first == last && (first == '"' || first == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+// Loads config values from JSON-formatted data.
+func (parser *ArgParser) loadJSONConfig(filename string, data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	// Returns the line number of the decoder's current position.
+	lineAt := func(offset int64) int {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	err := expectJSONDelim(decoder, '{')
+	if err == nil {
+		err = parser.loadJSONObject(decoder, "", func() string {
+			return fmt.Sprintf("%s:%d", filename, lineAt(decoder.InputOffset()))
+		})
+	}
+	if err == nil {
+		if _, tokenErr := decoder.Token(); tokenErr != io.EOF {
+			err = errors.New("unexpected data after top-level object")
+		}
+	}
+
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return fmt.Errorf("%s:%d: %w", filename, lineAt(syntaxErr.Offset), err)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("%s: unexpected end of JSON input", filename)
+		}
+		return fmt.Errorf("%s:%d: %w", filename, lineAt(decoder.InputOffset()), err)
+	}
+
+	return nil
+}
+
+// Loads the members of a JSON object from the decoder into the parser. The object's opening brace
+// has already been consumed. The path parameter is the object's key path for use in error messages.
+// The location function returns the file name and line number of the decoder's current position.
+func (parser *ArgParser) loadJSONObject(decoder *json.Decoder, path string, location func() string) error {
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+
+		token, err = decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		// Nested objects map to map options or commands.
+		if opt, found := parser.options[key]; found && opt.kind == "map" && token == json.Delim('{') {
+			values, err := readJSONMap(decoder, keyPath, opt.Separator)
+			if err != nil {
+				return err
+			}
+			if err := opt.setConfigValues(values, location()); err != nil {
+				return fmt.Errorf("%s: %w", keyPath, err)
+			}
+			continue
+		}
+
+		if token == json.Delim('{') {
+			cmdParser, found := parser.commands[key]
+			if !found {
+				return fmt.Errorf("'%s' is not a recognised command name", keyPath)
+			}
+			if err := cmdParser.loadJSONObject(decoder, keyPath, location); err != nil {
+				return err
+			}
+			continue
+		}
+
+		opt, found := parser.options[key]
+		if !found {
+			return fmt.Errorf("'%s' is not a recognised flag or option name", keyPath)
+		}
+
+		var values []string
+		if token == json.Delim('[') {
+			values = make([]string, 0)
+			for decoder.More() {
+				if token, err = decoder.Token(); err != nil {
+					return err
+				}
+				value, err := jsonScalarToString(token, keyPath)
+				if err != nil {
+					return err
+				}
+				values = append(values, value)
+			}
+			if err := expectJSONDelim(decoder, ']'); err != nil {
+				return err
+			}
+		} else {
+			value, err := jsonScalarToString(token, keyPath)
+			if err != nil {
+				return err
+			}
+			values = []string{value}
+		}
+
+		if err := opt.setConfigValues(values, location()); err != nil {
+			return fmt.Errorf("%s: %w", keyPath, err)
+		}
+	}
+
+	return expectJSONDelim(decoder, '}')
+}
+
+// Consumes the next token from the decoder, returning an error if it isn't the expected delimiter.
+func expectJSONDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected '%v', found '%v'", delim, token)
+	}
+	return nil
+}
+
+// Reads the members of a JSON object as a list of key-value pairs joined by separator. The object's
+// opening brace has already been consumed.
+func readJSONMap(decoder *json.Decoder, keyPath string, separator string) ([]string, error) {
+	if separator == "" {
+		separator = "="
+	}
+	values := make([]string, 0)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := token.(string)
+		if token, err = decoder.Token(); err != nil {
+			return nil, err
+		}
+		value, err := jsonScalarToString(token, keyPath+"."+key)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, key+separator+value)
+	}
+	return values, expectJSONDelim(decoder, '}')
+}
+
+// Returns a JSON scalar token in string form.
+func jsonScalarToString(token json.Token, keyPath string) (string, error) {
+	switch value := token.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	}
+	return "", fmt.Errorf("%s: unsupported value '%v'", keyPath, token)
+}
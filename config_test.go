@@ -0,0 +1,211 @@
+package argo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/* --------------- */
+/*  Config files.  */
+/* --------------- */
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigINI(t *testing.T) {
+	path := writeConfigFile(t, "config.ini", `
+# A comment.
+bool
+string = "value"
+int = 202
+list = a
+list = b
+
+[cmd]
+float = 2.2
+`)
+	parser := NewParser()
+	parser.NewFlag("bool")
+	parser.NewStringOption("string", "default")
+	parser.NewIntOption("int", 101)
+	parser.NewStringOption("list", "default")
+	cmdParser := parser.NewCommand("cmd")
+	cmdParser.NewFloatOption("float", 1.1)
+
+	if err := parser.LoadConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored", "--int", "303", "cmd"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.Found("bool") != true {
+		t.Fail()
+	}
+	if parser.StringValue("string") != "value" {
+		t.Fail()
+	}
+	if parser.IntValue("int") != 303 {
+		t.Fail()
+	}
+	if len(parser.StringValues("list")) != 2 {
+		t.Fail()
+	}
+	if cmdParser.FloatValue("float") != 2.2 {
+		t.Fail()
+	}
+}
+
+func TestConfigJSON(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{
+		"bool": true,
+		"string": "value",
+		"list": [1, 2, 3],
+		"cmd": {"float": 2.2}
+	}`)
+	parser := NewParser()
+	parser.NewFlag("bool")
+	parser.NewStringOption("string", "default")
+	parser.NewIntOption("list", 101)
+	cmdParser := parser.NewCommand("cmd")
+	cmdParser.NewFloatOption("float", 1.1)
+
+	if err := parser.LoadConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored", "cmd"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.Found("bool") != true {
+		t.Fail()
+	}
+	if parser.StringValue("string") != "value" {
+		t.Fail()
+	}
+	if len(parser.IntValues("list")) != 3 || parser.IntValue("list") != 3 {
+		t.Fail()
+	}
+	if cmdParser.FloatValue("float") != 2.2 {
+		t.Fail()
+	}
+}
+
+func TestConfigLayered(t *testing.T) {
+	first := writeConfigFile(t, "first.ini", "string = first\nint = 1\n")
+	second := writeConfigFile(t, "second.json", `{"string": "second"}`)
+	parser := NewParser()
+	parser.NewStringOption("string", "default")
+	parser.NewIntOption("int", 101)
+
+	if err := parser.LoadConfigFile(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.LoadConfigFile(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.StringValue("string") != "second" {
+		t.Fail()
+	}
+	if parser.IntValue("int") != 1 {
+		t.Fail()
+	}
+}
+
+func TestConfigEnvPrecedence(t *testing.T) {
+	t.Setenv("ARGO_TEST_STRING", "env")
+	path := writeConfigFile(t, "config.ini", "string = file\n")
+	parser := NewParser()
+	parser.NewStringOption("string", "default").Env = "ARGO_TEST_STRING"
+
+	if err := parser.LoadConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.StringValue("string") != "env" {
+		t.Fail()
+	}
+}
+
+func TestConfigINIInvalidValue(t *testing.T) {
+	path := writeConfigFile(t, "config.ini", "\n[cmd]\nint = abc\n")
+	parser := NewParser()
+	parser.NewCommand("cmd").NewIntOption("int", 101)
+	err := parser.LoadConfigFile(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if err.Error() != path+":3: int: cannot parse 'abc' as an integer" {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestConfigINIUnknownName(t *testing.T) {
+	path := writeConfigFile(t, "config.ini", "foo = bar\n")
+	parser := NewParser()
+	err := parser.LoadConfigFile(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if err.Error() != path+":1: 'foo' is not a recognised flag or option name" {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestConfigJSONInvalidValue(t *testing.T) {
+	path := writeConfigFile(t, "config.json", "{\n  \"cmd\": {\n    \"bool\": \"maybe\"\n  }\n}")
+	parser := NewParser()
+	parser.NewCommand("cmd").NewFlag("bool")
+	err := parser.LoadConfigFile(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if err.Error() != path+":3: cmd.bool: cannot parse 'maybe' as a boolean" {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestConfigMapOption(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{"label": {"env": "prod", "replicas": 3}}`)
+	parser := NewParser()
+	parser.NewMapOption("label")
+	if err := parser.LoadConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	labels := parser.MapValue("label")
+	if labels["env"] != "prod" || labels["replicas"] != "3" {
+		t.Fail()
+	}
+}
+
+func TestConfigNegatableFlag(t *testing.T) {
+	path := writeConfigFile(t, "config.ini", "color = false\nverbose = false\n")
+	parser := NewParser()
+	parser.NewFlag("color").Negatable = true
+	parser.NewFlag("verbose")
+
+	if err := parser.LoadConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.FlagState("color") != FlagFalse {
+		t.Fail()
+	}
+	if parser.FlagState("verbose") != FlagUnset {
+		t.Fail()
+	}
+}
@@ -0,0 +1,67 @@
+package argo
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+/* ----------------------------------- */
+/*  ArgParser: environment variables.  */
+/* ----------------------------------- */
+
+// Returns the name of the environment variable bound to the option or the empty string if the
+// option isn't bound to an environment variable.
+func (parser *ArgParser) envName(opt *Option) string {
+	if opt.Env != "" {
+		return opt.Env
+	}
+	if parser.EnvPrefix == "" {
+		return ""
+	}
+	for _, alias := range opt.aliases {
+		if len([]rune(alias)) > 1 {
+			name := strings.ToUpper(strings.ReplaceAll(alias, "-", "_"))
+			return parser.EnvPrefix + "_" + name
+		}
+	}
+	return ""
+}
+
+// Reads values from bound environment variables for any options not found on the command line.
+// Options in the excluded set are skipped.
+func (parser *ArgParser) applyEnv(excluded map[*Option]bool) error {
+	for _, opt := range parser.optionList {
+		if opt.count > 0 || excluded[opt] {
+			continue
+		}
+
+		name := parser.envName(opt)
+		if name == "" {
+			continue
+		}
+
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+
+		if opt.kind == "flag" {
+			found, err := strconv.ParseBool(value)
+			if err != nil {
+				err = fmt.Errorf("cannot parse '%s' as a boolean", value)
+				return parser.invalidSourceValueError(opt, value, "environment variable "+name, err)
+			}
+			opt.setFlag(found)
+			continue
+		}
+
+		if err := opt.tryAppendValue(value); err != nil {
+			return parser.invalidSourceValueError(opt, value, "environment variable "+name, err)
+		}
+		opt.count = 1
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package argo
+
+import "testing"
+
+/* ------------------------ */
+/*  Environment variables.  */
+/* ------------------------ */
+
+func TestEnvExplicitName(t *testing.T) {
+	t.Setenv("ARGO_TEST_STRING", "env")
+	parser := NewParser()
+	parser.NewStringOption("string", "default").Env = "ARGO_TEST_STRING"
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.StringValue("string") != "env" {
+		t.Fail()
+	}
+	if parser.Found("string") != true {
+		t.Fail()
+	}
+}
+
+func TestEnvCommandLinePrecedence(t *testing.T) {
+	t.Setenv("ARGO_TEST_INT", "123")
+	parser := NewParser()
+	parser.NewIntOption("int", 101).Env = "ARGO_TEST_INT"
+	if err := parser.Parse([]string{"ignored", "--int", "456"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.IntValue("int") != 456 {
+		t.Fail()
+	}
+	if len(parser.IntValues("int")) != 1 {
+		t.Fail()
+	}
+}
+
+func TestEnvEmptyUsesFallback(t *testing.T) {
+	t.Setenv("ARGO_TEST_FLOAT", "")
+	parser := NewParser()
+	parser.NewFloatOption("float", 1.5).Env = "ARGO_TEST_FLOAT"
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.FloatValue("float") != 1.5 {
+		t.Fail()
+	}
+	if parser.Found("float") != false {
+		t.Fail()
+	}
+}
+
+func TestEnvPrefix(t *testing.T) {
+	t.Setenv("APP_DRY_RUN", "true")
+	t.Setenv("APP_OUTPUT_DIR", "out")
+	parser := NewParser()
+	parser.EnvPrefix = "APP"
+	parser.NewFlag("d dry-run")
+	parser.NewStringOption("output-dir", "default")
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.Found("dry-run") != true {
+		t.Fail()
+	}
+	if parser.StringValue("output-dir") != "out" {
+		t.Fail()
+	}
+}
+
+func TestEnvFlagFalse(t *testing.T) {
+	t.Setenv("ARGO_TEST_FLAG", "false")
+	parser := NewParser()
+	parser.NewFlag("flag").Env = "ARGO_TEST_FLAG"
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.Found("flag") != false {
+		t.Fail()
+	}
+}
+
+func TestEnvInvalidValue(t *testing.T) {
+	t.Setenv("ARGO_TEST_INT", "abc")
+	parser := NewParser()
+	parser.NewIntOption("int", 101).Env = "ARGO_TEST_INT"
+	err := parser.Parse([]string{"ignored"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if err.Error() != "environment variable ARGO_TEST_INT: cannot parse 'abc' as an integer" {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestEnvCommandParser(t *testing.T) {
+	t.Setenv("ARGO_TEST_STRING", "env")
+	parser := NewParser()
+	cmdParser := parser.NewCommand("cmd")
+	cmdParser.NewStringOption("string", "default").Env = "ARGO_TEST_STRING"
+	var value string
+	cmdParser.Callback = func(name string, cmdParser *ArgParser) error {
+		value = cmdParser.StringValue("string")
+		return nil
+	}
+	if err := parser.Parse([]string{"ignored", "cmd"}); err != nil {
+		t.Fatal(err)
+	}
+	if value != "env" {
+		t.Fail()
+	}
+}
+
+func TestEnvNegatableFlagFalse(t *testing.T) {
+	t.Setenv("ARGO_TEST_COLOR", "false")
+	parser := NewParser()
+	opt := parser.NewFlag("color")
+	opt.Negatable = true
+	opt.Env = "ARGO_TEST_COLOR"
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.FlagState("color") != FlagFalse {
+		t.Fail()
+	}
+}
+
+func TestEnvNegatableFlagOverridden(t *testing.T) {
+	t.Setenv("ARGO_TEST_COLOR", "true")
+	parser := NewParser()
+	opt := parser.NewFlag("color")
+	opt.Negatable = true
+	opt.Env = "ARGO_TEST_COLOR"
+	if err := parser.Parse([]string{"ignored", "--no-color"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.FlagState("color") != FlagFalse {
+		t.Fail()
+	}
+}
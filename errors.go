@@ -0,0 +1,197 @@
+package argo
+
+import "errors"
+
+/* --------------- */
+/*  Parse errors.  */
+/* --------------- */
+
+// Sentinel errors returned by Parse() after printing helptext, a version string, or completions if
+// the parser's NoExit setting is enabled.
+var (
+	ErrHelpRequested       = errors.New("argo: help requested")
+	ErrVersionRequested    = errors.New("argo: version requested")
+	ErrCompletionRequested = errors.New("argo: completion requested")
+)
+
+// Parse() returns one of the error types below for errors in the parsed arguments. Use errors.As()
+// to inspect an error's fields, e.g.
+//
+//	var unknown *argo.UnknownOptionError
+//	if errors.As(err, &unknown) {
+//		fmt.Println(unknown.Option, unknown.Index)
+//	}
+//
+// Each Index field is the index of the relevant argument in the slice of arguments passed to
+// Parse(), where index 0 is the application's path. Values read from environment variables and
+// config files have an Index of -1. Each CommandPath field lists the names of the commands leading
+// to the parser that returned the error, e.g. ["build", "test"], and is empty for the root parser.
+
+// UnknownOptionError is returned by Parse() if an argument isn't a recognised flag or option name,
+// or is an ambiguous abbreviation.
+type UnknownOptionError struct {
+	// The unrecognised flag or option name including its leading dashes, e.g. "--verbos".
+	Option      string
+	Arg         string
+	Index       int
+	CommandPath []string
+	message     string
+}
+
+func (err *UnknownOptionError) Error() string {
+	return err.message
+}
+
+// MissingValueError is returned by Parse() if an option is missing its value.
+type MissingValueError struct {
+	// The option's name including its leading dashes, e.g. "--output".
+	Option      string
+	Arg         string
+	Index       int
+	CommandPath []string
+	message     string
+}
+
+func (err *MissingValueError) Error() string {
+	return err.message
+}
+
+// InvalidValueError is returned by Parse() if an option's value can't be parsed as the option's
+// type, isn't one of the option's choices, or is assigned to a flag. The Err field stores the
+// underlying error, if any. Arg and Index refer to the argument containing the option's name, e.g.
+// "--count" for "--count abc" or "--count=abc" for "--count=abc".
+type InvalidValueError struct {
+	// The option's name including its leading dashes, e.g. "--count".
+	Option      string
+	Value       string
+	Arg         string
+	Index       int
+	CommandPath []string
+	Err         error
+	message     string
+}
+
+func (err *InvalidValueError) Error() string {
+	return err.message
+}
+
+func (err *InvalidValueError) Unwrap() error {
+	return err.Err
+}
+
+// UnknownCommandError is returned by Parse() if an argument is a misspelled or ambiguous command
+// name, or if the argument of the automatic 'help' command isn't a recognised command name.
+type UnknownCommandError struct {
+	Command     string
+	Arg         string
+	Index       int
+	CommandPath []string
+	message     string
+}
+
+func (err *UnknownCommandError) Error() string {
+	return err.message
+}
+
+// CommandArgumentError is returned by Parse() if the argument of the automatic 'help' or
+// 'completion' command is missing, or if the argument of the 'completion' command isn't a supported
+// shell. If the argument is missing, Arg and Index refer to the command itself.
+type CommandArgumentError struct {
+	// The name of the automatic command, i.e. "help" or "completion".
+	Command     string
+	Arg         string
+	Index       int
+	CommandPath []string
+	message     string
+}
+
+func (err *CommandArgumentError) Error() string {
+	return err.message
+}
+
+// ValidationError is returned by Parse() if a required option is missing or if the parsed options
+// violate one of the parser's option groups.
+type ValidationError struct {
+	// The names of the options involved including their leading dashes, e.g. ["--output"].
+	Options     []string
+	CommandPath []string
+	message     string
+}
+
+func (err *ValidationError) Error() string {
+	return err.message
+}
+
+// LineSyntaxError is returned by SplitLine() and ParseLine() if a command line string contains an
+// unterminated quote or ends with a backslash.
+type LineSyntaxError struct {
+	// The 1-based column of the unterminated quote or trailing backslash, counted in characters.
+	Column  int
+	message string
+}
+
+func (err *LineSyntaxError) Error() string {
+	return err.message
+}
+
+// Returns the names of the commands leading from the root parser to the parser.
+func (parser *ArgParser) commandNames() []string {
+	names := make([]string, 0)
+	for p := parser; p.parent != nil; p = p.parent {
+		names = append([]string{p.aliases[0]}, names...)
+	}
+	return names
+}
+
+// Returns the argument most recently read from the stream and its index in the argument vector
+// passed to Parse().
+func (stream *argstream) current() (string, int) {
+	if stream.index == 0 {
+		return "", 0
+	}
+	return stream.args[stream.index-1], stream.index
+}
+
+func (parser *ArgParser) unknownOptionError(option string, stream *argstream, message string) error {
+	arg, index := stream.current()
+	return &UnknownOptionError{option, arg, index, parser.commandNames(), message}
+}
+
+func (parser *ArgParser) missingValueError(option string, stream *argstream, message string) error {
+	arg, index := stream.current()
+	return &MissingValueError{option, arg, index, parser.commandNames(), message}
+}
+
+func (parser *ArgParser) unknownCommandError(command string, stream *argstream, message string) error {
+	arg, index := stream.current()
+	return &UnknownCommandError{command, arg, index, parser.commandNames(), message}
+}
+
+func (parser *ArgParser) commandArgumentError(command string, stream *argstream, message string) error {
+	arg, index := stream.current()
+	return &CommandArgumentError{command, arg, index, parser.commandNames(), message}
+}
+
+// Wraps an error returned while parsing an option's value read from an environment variable or
+// config file. The source parameter identifies the variable or file for use in the error message.
+func (parser *ArgParser) invalidSourceValueError(opt *Option, value string, source string, err error) error {
+	name := optionPrefix(opt.aliases[0]) + opt.aliases[0]
+	return &InvalidValueError{name, value, "", -1, parser.commandNames(), err, source + ": " + err.Error()}
+}
+
+// Wraps an error returned while parsing an option's value. The index parameter is the index of the
+// option's own argument, which may precede the value's argument. Returns nil if err is nil.
+func (parser *ArgParser) invalidValueError(option string, value string, stream *argstream, index int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &InvalidValueError{option, value, stream.args[index-1], index, parser.commandNames(), err, err.Error()}
+}
+
+func (parser *ArgParser) validationError(options []*Option, message string) error {
+	names := make([]string, 0, len(options))
+	for _, opt := range options {
+		names = append(names, optionPrefix(opt.aliases[0])+opt.aliases[0])
+	}
+	return &ValidationError{names, parser.commandNames(), message}
+}
@@ -0,0 +1,222 @@
+package argo
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+/* --------------- */
+/*  Parse errors.  */
+/* --------------- */
+
+func TestUnknownOptionError(t *testing.T) {
+	parser := NewParser()
+	cmdParser := parser.NewCommand("build")
+	cmdParser.NewFlag("verbose")
+	err := parser.Parse([]string{"ignored", "build", "--verbos"})
+
+	var unknown *UnknownOptionError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if unknown.Option != "--verbos" || unknown.Arg != "--verbos" || unknown.Index != 2 {
+		t.Errorf("unexpected fields: %+v", unknown)
+	}
+	if len(unknown.CommandPath) != 1 || unknown.CommandPath[0] != "build" {
+		t.Errorf("unexpected command path: %q", unknown.CommandPath)
+	}
+}
+
+func TestUnknownOptionErrorCondensed(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("a")
+	err := parser.Parse([]string{"ignored", "-ax"})
+
+	var unknown *UnknownOptionError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if unknown.Option != "-x" || unknown.Arg != "-ax" || unknown.Index != 1 {
+		t.Errorf("unexpected fields: %+v", unknown)
+	}
+}
+
+func TestMissingValueError(t *testing.T) {
+	parser := NewParser()
+	parser.NewStringOption("output o", "")
+	err := parser.Parse([]string{"ignored", "arg", "-o"})
+
+	var missing *MissingValueError
+	if !errors.As(err, &missing) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if missing.Option != "-o" || missing.Arg != "-o" || missing.Index != 2 {
+		t.Errorf("unexpected fields: %+v", missing)
+	}
+	if err.Error() != "missing argument for option -o" {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestInvalidValueError(t *testing.T) {
+	parser := NewParser()
+	parser.NewIntOption("count", 0)
+	err := parser.Parse([]string{"ignored", "--count", "abc"})
+
+	var invalid *InvalidValueError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if invalid.Option != "--count" || invalid.Value != "abc" || invalid.Arg != "--count" || invalid.Index != 1 {
+		t.Errorf("unexpected fields: %+v", invalid)
+	}
+	if err.Error() != "cannot parse 'abc' as an integer" {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestInvalidValueErrorEquals(t *testing.T) {
+	parser := NewParser()
+	parser.NewIntOption("count", 0)
+	err := parser.Parse([]string{"ignored", "--count=abc"})
+
+	var invalid *InvalidValueError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if invalid.Option != "--count" || invalid.Value != "abc" || invalid.Arg != "--count=abc" || invalid.Index != 1 {
+		t.Errorf("unexpected fields: %+v", invalid)
+	}
+}
+
+func TestInvalidValueErrorShortForm(t *testing.T) {
+	parser := NewParser()
+	parser.NewIntOption("num n", 0)
+	parser.NewFlag("a")
+	for _, args := range [][]string{{"ignored", "-n", "x"}, {"ignored", "-an", "x"}, {"ignored", "-n=x"}} {
+		err := parser.Parse(args)
+
+		var invalid *InvalidValueError
+		if !errors.As(err, &invalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if invalid.Option != "-n" || invalid.Value != "x" || invalid.Arg != args[1] || invalid.Index != 1 {
+			t.Errorf("unexpected fields: %+v", invalid)
+		}
+	}
+}
+
+// A custom value type that returns the underlying strconv error.
+type portValue int
+
+func (p *portValue) Set(arg string) error {
+	value, err := strconv.Atoi(arg)
+	*p = portValue(value)
+	return err
+}
+
+func (p *portValue) Type() string {
+	return "port"
+}
+
+func TestInvalidValueErrorUnwrap(t *testing.T) {
+	parser := NewParser()
+	parser.NewValueOption("port", new(portValue))
+	err := parser.Parse([]string{"ignored", "--port", "abc"})
+
+	var invalid *InvalidValueError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		t.Errorf("expected a wrapped *strconv.NumError: %v", err)
+	}
+}
+
+func TestInvalidValueErrorEnv(t *testing.T) {
+	t.Setenv("ARGO_TEST_INT", "abc")
+	parser := NewParser()
+	parser.NewIntOption("int", 0).Env = "ARGO_TEST_INT"
+	err := parser.Parse([]string{"ignored"})
+
+	var invalid *InvalidValueError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if invalid.Option != "--int" || invalid.Value != "abc" || invalid.Index != -1 {
+		t.Errorf("unexpected fields: %+v", invalid)
+	}
+}
+
+func TestUnknownCommandError(t *testing.T) {
+	parser := NewParser()
+	parser.NewCommand("build")
+	err := parser.Parse([]string{"ignored", "help", "buidl"})
+
+	var unknown *UnknownCommandError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if unknown.Command != "buidl" || unknown.Arg != "buidl" || unknown.Index != 2 {
+		t.Errorf("unexpected fields: %+v", unknown)
+	}
+}
+
+func TestCommandArgumentError(t *testing.T) {
+	parser := NewParser()
+	cmdParser := parser.NewCommand("build")
+	cmdParser.EnableHelpCommand = true
+	cmdParser.NewCommand("test")
+	err := parser.Parse([]string{"ignored", "build", "help"})
+
+	var invalid *CommandArgumentError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if invalid.Command != "help" || invalid.Arg != "help" || invalid.Index != 2 {
+		t.Errorf("unexpected fields: %+v", invalid)
+	}
+	if len(invalid.CommandPath) != 1 || invalid.CommandPath[0] != "build" {
+		t.Errorf("unexpected command path: %q", invalid.CommandPath)
+	}
+	if err.Error() != "help: missing argument for the help command" {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestCommandArgumentErrorCompletion(t *testing.T) {
+	parser := NewParser()
+	parser.EnableCompletionCommand = true
+	err := parser.Parse([]string{"ignored", "completion", "tcsh"})
+
+	var invalid *CommandArgumentError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if invalid.Command != "completion" || invalid.Arg != "tcsh" || invalid.Index != 2 {
+		t.Errorf("unexpected fields: %+v", invalid)
+	}
+
+	err = parser.Parse([]string{"ignored", "completion"})
+	if !errors.As(err, &invalid) || invalid.Arg != "completion" || invalid.Index != 1 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("json")
+	parser.NewFlag("yaml")
+	parser.MutuallyExclusive("json", "yaml")
+	err := parser.Parse([]string{"ignored", "--json", "--yaml"})
+
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(invalid.Options) != 2 || invalid.Options[0] != "--json" || invalid.Options[1] != "--yaml" {
+		t.Errorf("unexpected options: %q", invalid.Options)
+	}
+}
@@ -0,0 +1,209 @@
+package argo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/* ---------------------- */
+/*  ArgParser: helptext.  */
+/* ---------------------- */
+
+// The maximum width of the name column in generated helptext. Names longer than this are printed
+// on a line of their own with the description on the following line.
+const helpColumnWidth = 28
+
+// Returns the parser's helptext, generating it automatically if the Helptext field is empty.
+func (parser *ArgParser) helptext() string {
+	if parser.Helptext != "" {
+		return strings.TrimSpace(parser.Helptext)
+	}
+	return parser.generateHelptext()
+}
+
+// Returns the parser's command path for use in generated helptext, e.g. "app cmd subcmd".
+func (parser *ArgParser) commandPath() string {
+	if parser.parent != nil {
+		return parser.parent.commandPath() + " " + parser.aliases[0]
+	}
+	if parser.Name != "" {
+		return parser.Name
+	}
+	if parser.appName != "" {
+		return parser.appName
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// Generates a helptext string from the parser's registered flags, options, and commands.
+func (parser *ArgParser) generateHelptext() string {
+	var builder strings.Builder
+
+	builder.WriteString("Usage: " + parser.commandPath() + " [options]")
+	if len(parser.commandList) > 0 {
+		builder.WriteString(" <command>")
+	}
+	builder.WriteString("\n")
+
+	if parser.Description != "" {
+		builder.WriteString("\n" + indent(strings.TrimSpace(parser.Description), "  ") + "\n")
+	}
+
+	rows := make([][2]string, 0, len(parser.optionList)+2)
+	for _, opt := range parser.optionList {
+		rows = append(rows, parser.helpRow(opt))
+	}
+	if _, found := parser.options["help"]; !found {
+		rows = append(rows, [2]string{parser.builtinFlagName("help", "h"), "Print this helptext and exit."})
+	}
+	if _, found := parser.options["version"]; !found && parser.Version != "" {
+		rows = append(rows, [2]string{parser.builtinFlagName("version", "v"), "Print the version number and exit."})
+	}
+	builder.WriteString("\nOptions:\n")
+	builder.WriteString(formatHelpRows(rows))
+
+	// Persistent flags and options inherited from ancestor parsers.
+	if inherited := parser.visibleOptions()[len(parser.optionList):]; len(inherited) > 0 {
+		rows = rows[:0]
+		for _, opt := range inherited {
+			for p := parser.parent; p != nil; p = p.parent {
+				if p.options[opt.aliases[0]] == opt {
+					rows = append(rows, p.helpRow(opt))
+					break
+				}
+			}
+		}
+		builder.WriteString("\nGlobal options:\n")
+		builder.WriteString(formatHelpRows(rows))
+	}
+
+	if len(parser.commandList) > 0 || parser.EnableCompletionCommand {
+		rows = rows[:0]
+		for _, cmdParser := range parser.commandList {
+			rows = append(rows, [2]string{strings.Join(cmdParser.aliases, ", "), cmdParser.Description})
+		}
+		if _, found := parser.commands["help"]; !found && parser.EnableHelpCommand {
+			rows = append(rows, [2]string{"help <command>", "Print the helptext for a command."})
+		}
+		if _, found := parser.commands["completion"]; !found && parser.EnableCompletionCommand {
+			rows = append(rows, [2]string{"completion <shell>", "Print a shell completion script."})
+		}
+		builder.WriteString("\nCommands:\n")
+		builder.WriteString(formatHelpRows(rows))
+	}
+
+	return strings.TrimSpace(builder.String())
+}
+
+// Returns the helptext row for one of the parser's registered flags or options.
+func (parser *ArgParser) helpRow(opt *Option) [2]string {
+	description := opt.helpDescription()
+	if env := parser.envName(opt); env != "" {
+		description = strings.TrimSpace(description + " Env: " + env + ".")
+	}
+	if opt.Required {
+		description = strings.TrimSpace(description + " Required.")
+	}
+	return [2]string{opt.helpName(), description}
+}
+
+// Returns the display name for an automatic flag, including its shortcut if the shortcut hasn't
+// been registered by another flag or option.
+func (parser *ArgParser) builtinFlagName(name string, shortcut string) string {
+	if _, found := parser.options[shortcut]; found {
+		return "--" + name
+	}
+	return "-" + shortcut + ", --" + name
+}
+
+// Returns the option's display name for generated helptext, e.g. "-o, --opt <int>".
+func (opt *Option) helpName() string {
+	shortcuts := make([]string, 0)
+	longnames := make([]string, 0)
+	for _, alias := range opt.aliases {
+		if len([]rune(alias)) == 1 {
+			shortcuts = append(shortcuts, "-"+alias)
+		} else if opt.Negatable && opt.kind == "flag" {
+			longnames = append(longnames, "--[no-]"+alias)
+		} else {
+			longnames = append(longnames, "--"+alias)
+		}
+	}
+
+	name := strings.Join(append(shortcuts, longnames...), ", ")
+	value := "<" + opt.typeName() + ">"
+	if len(opt.choices) > 0 {
+		value = "<" + strings.Join(opt.choices, "|") + ">"
+	}
+	switch {
+	case opt.kind == "flag":
+		return name
+	case opt.OptionalValue:
+		return name + "[=" + value + "]"
+	}
+	return name + " " + value
+}
+
+// Returns the option's description for generated helptext, including its default value.
+func (opt *Option) helpDescription() string {
+	var fallback string
+	switch opt.kind {
+	case "string", "custom":
+		fallback = opt.stringFallback
+	case "int":
+		fallback = fmt.Sprintf("%v", opt.intFallback)
+	case "float":
+		fallback = fmt.Sprintf("%v", opt.floatFallback)
+	case "duration":
+		fallback = opt.durationFallback.String()
+	case "time":
+		if !opt.timeFallback.IsZero() {
+			fallback = opt.timeFallback.Format(time.RFC3339)
+		}
+	}
+
+	if fallback == "" {
+		return opt.Description
+	}
+	if opt.Description == "" {
+		return fmt.Sprintf("Default: %s.", fallback)
+	}
+	return fmt.Sprintf("%s Default: %s.", opt.Description, fallback)
+}
+
+// Formats a list of (name, description) pairs as an indented two-column table.
+func formatHelpRows(rows [][2]string) string {
+	width := 0
+	for _, row := range rows {
+		if len(row[0]) > width && len(row[0]) <= helpColumnWidth {
+			width = len(row[0])
+		}
+	}
+
+	var builder strings.Builder
+	for _, row := range rows {
+		if row[1] == "" {
+			builder.WriteString("  " + row[0] + "\n")
+		} else if len(row[0]) > helpColumnWidth {
+			builder.WriteString("  " + row[0] + "\n")
+			builder.WriteString(strings.Repeat(" ", width+4) + row[1] + "\n")
+		} else {
+			builder.WriteString(fmt.Sprintf("  %-*s  %s\n", width, row[0], row[1]))
+		}
+	}
+	return builder.String()
+}
+
+// Prefixes each non-empty line in text with the specified indent string.
+func indent(text string, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
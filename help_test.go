@@ -0,0 +1,227 @@
+package argo
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+/* ----------- */
+/*  Helptext.  */
+/* ----------- */
+
+func TestHelptextOverride(t *testing.T) {
+	parser := NewParser()
+	parser.Helptext = "\n  Usage: custom\n"
+	parser.NewFlag("bool b").Description = "A flag."
+	if parser.helptext() != "Usage: custom" {
+		t.Fail()
+	}
+}
+
+func TestHelptextGenerated(t *testing.T) {
+	parser := NewParser()
+	parser.Description = "An example application."
+	parser.Version = "1.0"
+	parser.NewFlag("bool b").Description = "A flag."
+	parser.NewStringOption("string s", "default").Description = "A string option."
+	parser.NewIntOption("int", 101)
+	parser.Parse([]string{"app"})
+
+	expected := strings.Join([]string{
+		"Usage: app [options]",
+		"",
+		"  An example application.",
+		"",
+		"Options:",
+		"  -b, --bool             A flag.",
+		"  -s, --string <string>  A string option. Default: default.",
+		"  --int <int>            Default: 101.",
+		"  -h, --help             Print this helptext and exit.",
+		"  -v, --version          Print the version number and exit.",
+	}, "\n")
+
+	if parser.helptext() != expected {
+		t.Errorf("unexpected helptext:\n%s", parser.helptext())
+	}
+}
+
+func TestHelptextGeneratedPersistentOptions(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("verbose").Persistent = true
+	parser.NewFlag("quiet")
+	cmdParser := parser.NewCommand("build")
+	cmdParser.NewFlag("release r").Description = "A release build."
+	parser.Parse([]string{"app", "build"})
+
+	expected := strings.Join([]string{
+		"Usage: app build [options]",
+		"",
+		"Options:",
+		"  -r, --release  A release build.",
+		"  -h, --help     Print this helptext and exit.",
+		"",
+		"Global options:",
+		"  --verbose",
+	}, "\n")
+
+	if cmdParser.helptext() != expected {
+		t.Errorf("unexpected helptext:\n%s", cmdParser.helptext())
+	}
+}
+
+func TestHelptextGeneratedShortcutConflict(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("host h")
+	parser.Parse([]string{"app"})
+	helptext := parser.helptext()
+	if !strings.Contains(helptext, "  --help ") {
+		t.Fail()
+	}
+	if strings.Contains(helptext, "-h, --help") {
+		t.Fail()
+	}
+}
+
+func TestHelptextGeneratedCommands(t *testing.T) {
+	parser := NewParser()
+	parser.Name = "app"
+	cmdParser := parser.NewCommand("build b")
+	cmdParser.Description = "Build the project."
+	cmdParser.NewFlag("release r").Description = "Build in release mode."
+
+	expected := strings.Join([]string{
+		"Usage: app [options] <command>",
+		"",
+		"Options:",
+		"  -h, --help  Print this helptext and exit.",
+		"",
+		"Commands:",
+		"  build, b        Build the project.",
+		"  help <command>  Print the helptext for a command.",
+	}, "\n")
+	if parser.helptext() != expected {
+		t.Errorf("unexpected helptext:\n%s", parser.helptext())
+	}
+
+	if !strings.HasPrefix(cmdParser.helptext(), "Usage: app build [options]\n\n  Build the project.") {
+		t.Errorf("unexpected helptext:\n%s", cmdParser.helptext())
+	}
+}
+
+func TestHelptextChoiceOption(t *testing.T) {
+	parser := NewParser()
+	parser.NewChoiceOption("format f", "json", "json", "yaml")
+	if !strings.Contains(parser.helptext(), "  -f, --format <json|yaml>  Default: json.") {
+		t.Errorf("unexpected helptext:\n%s", parser.helptext())
+	}
+}
+
+func TestHelptextNegatableFlag(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("color c").Negatable = true
+	if !strings.Contains(parser.helptext(), "  -c, --[no-]color") {
+		t.Errorf("unexpected helptext:\n%s", parser.helptext())
+	}
+}
+
+func TestHelptextOptionalValue(t *testing.T) {
+	parser := NewParser()
+	opt := parser.NewChoiceOption("color c", "auto", "always", "never", "auto")
+	opt.OptionalValue = true
+	if !strings.Contains(parser.helptext(), "  -c, --color[=<always|never|auto>]") {
+		t.Errorf("unexpected helptext:\n%s", parser.helptext())
+	}
+}
+
+/* ------------------- */
+/*  Output and exits.  */
+/* ------------------- */
+
+func TestHelpFlagNoExit(t *testing.T) {
+	var stdout bytes.Buffer
+	parser := NewParser()
+	parser.Helptext = "Usage: app"
+	parser.Stdout = &stdout
+	parser.NoExit = true
+	err := parser.Parse([]string{"ignored", "--help"})
+	if !errors.Is(err, ErrHelpRequested) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if stdout.String() != "Usage: app\n" {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+}
+
+func TestVersionFlagNoExit(t *testing.T) {
+	var stdout bytes.Buffer
+	parser := NewParser()
+	parser.Version = "1.2.3"
+	parser.Stdout = &stdout
+	parser.NoExit = true
+	err := parser.Parse([]string{"ignored", "-v"})
+	if !errors.Is(err, ErrVersionRequested) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if stdout.String() != "1.2.3\n" {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+}
+
+func TestHelpCommandInheritsOutput(t *testing.T) {
+	var stdout bytes.Buffer
+	parser := NewParser()
+	parser.Stdout = &stdout
+	parser.NoExit = true
+	parser.NewCommand("build").Helptext = "Usage: app build"
+	err := parser.Parse([]string{"ignored", "help", "build"})
+	if !errors.Is(err, ErrHelpRequested) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if stdout.String() != "Usage: app build\n" {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+
+	stdout.Reset()
+	err = parser.Parse([]string{"ignored", "build", "--help"})
+	if !errors.Is(err, ErrHelpRequested) || stdout.String() != "Usage: app build\n" {
+		t.Errorf("unexpected result: %v, %q", err, stdout.String())
+	}
+}
+
+func TestCustomExitFunction(t *testing.T) {
+	var stdout bytes.Buffer
+	exitCode := -1
+	parser := NewParser()
+	parser.Stdout = &stdout
+	parser.Exit = func(code int) { exitCode = code }
+	cmdParser := parser.NewCommand("build")
+	cmdParser.NewFlag("release")
+	err := parser.Parse([]string{"ignored", "build", "-h", "--release"})
+	if exitCode != 0 {
+		t.Fail()
+	}
+	if !errors.Is(err, ErrHelpRequested) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if cmdParser.Found("release") {
+		t.Fail()
+	}
+}
+
+func TestCompletionCommandNoExit(t *testing.T) {
+	var stdout bytes.Buffer
+	parser := NewParser()
+	parser.Name = "app"
+	parser.EnableCompletionCommand = true
+	parser.Stdout = &stdout
+	parser.NoExit = true
+	err := parser.Parse([]string{"ignored", "completion", "bash"})
+	if !errors.Is(err, ErrCompletionRequested) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "complete -o default -F _app") {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+}
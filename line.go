@@ -0,0 +1,101 @@
+package argo
+
+import (
+	"fmt"
+	"strings"
+)
+
+/* ----------------------- */
+/*  Command line strings.  */
+/* ----------------------- */
+
+// SplitLine splits a command line string into arguments using POSIX shell quoting rules.
+//
+// Arguments are separated by unquoted whitespace. A backslash outside quotes preserves the literal
+// value of the following character. Characters inside single quotes are preserved literally.
+// Characters inside double quotes are preserved literally except for a backslash, which escapes a
+// following '$', '`', '"', '\', or newline. A backslash-newline pair is removed. Quoted empty
+// strings produce empty arguments. No variable, command, or glob expansion is performed, e.g.
+//
+//	build --name "my app" 'it''s' a\ b
+//
+// is split into the arguments [build --name "my app" its "a b"].
+//
+// Returns a *LineSyntaxError if the line contains an unterminated quote or ends with a backslash.
+func SplitLine(line string) ([]string, error) {
+	args := make([]string, 0)
+	runes := []rune(line)
+
+	var arg strings.Builder
+	inArg := false
+
+	for i := 0; i < len(runes); i++ {
+		switch char := runes[i]; char {
+		case ' ', '\t', '\n', '\r':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+
+		case '\\':
+			if i+1 == len(runes) {
+				return nil, &LineSyntaxError{i + 1, fmt.Sprintf("trailing backslash at column %d", i+1)}
+			}
+			i += 1
+			if runes[i] != '\n' {
+				arg.WriteRune(runes[i])
+			}
+			inArg = true
+
+		case '\'':
+			start := i
+			for i += 1; i < len(runes) && runes[i] != '\''; i++ {
+				arg.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, &LineSyntaxError{start + 1, fmt.Sprintf("unterminated single quote at column %d", start+1)}
+			}
+			inArg = true
+
+		case '"':
+			start := i
+			for i += 1; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+					i += 1
+					if runes[i] != '\n' {
+						arg.WriteRune(runes[i])
+					}
+					continue
+				}
+				arg.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, &LineSyntaxError{start + 1, fmt.Sprintf("unterminated double quote at column %d", start+1)}
+			}
+			inArg = true
+
+		default:
+			arg.WriteRune(char)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// ParseLine parses a command line string, e.g. a line read from a chat message or a terminal. The
+// string is split into arguments using SplitLine() and shouldn't begin with the application's
+// path. Error indexes count the line's first argument as index 1.
+//
+// As with Parse(), the parsed values are stored on the parser itself.
+func (parser *ArgParser) ParseLine(line string) error {
+	args, err := SplitLine(line)
+	if err != nil {
+		return err
+	}
+	return parser.parseArgs(args)
+}
@@ -0,0 +1,116 @@
+package argo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+/* ----------------------- */
+/*  Command line strings.  */
+/* ----------------------- */
+
+func TestSplitLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{"", []string{}},
+		{"  build  --jobs\t4 ", []string{"build", "--jobs", "4"}},
+		{`--name "my app"`, []string{"--name", "my app"}},
+		{`'it''s' a\ b`, []string{"its", "a b"}},
+		{`'a "b" \c'`, []string{`a "b" \c`}},
+		{`"a \"b\" \c \\ \$x"`, []string{`a "b" \c \ $x`}},
+		{`"" ''`, []string{"", ""}},
+		{"foo\\\nbar", []string{"foobar"}},
+		{`pre"mid"'post'`, []string{"premidpost"}},
+		{`héllo "wörld"`, []string{"héllo", "wörld"}},
+	}
+	for _, test := range tests {
+		args, err := SplitLine(test.line)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.line, err)
+			continue
+		}
+		if len(args) != len(test.expected) {
+			t.Errorf("%q: unexpected args: %q", test.line, args)
+			continue
+		}
+		for i := range args {
+			if args[i] != test.expected[i] {
+				t.Errorf("%q: unexpected args: %q", test.line, args)
+				break
+			}
+		}
+	}
+}
+
+func TestSplitLineErrors(t *testing.T) {
+	tests := []struct {
+		line    string
+		column  int
+		message string
+	}{
+		{`build --name "my app`, 14, "unterminated double quote at column 14"},
+		{`é 'abc`, 3, "unterminated single quote at column 3"},
+		{`foo\`, 4, "trailing backslash at column 4"},
+	}
+	for _, test := range tests {
+		_, err := SplitLine(test.line)
+		var syntaxErr *LineSyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: unexpected error: %v", test.line, err)
+			continue
+		}
+		if syntaxErr.Column != test.column || err.Error() != test.message {
+			t.Errorf("%q: unexpected error: %v", test.line, err)
+		}
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	parser := NewParser()
+	cmdParser := parser.NewCommand("build")
+	cmdParser.NewStringOption("name n", "")
+	if err := parser.ParseLine(`build -n "my app" 'arg one'`); err != nil {
+		t.Fatal(err)
+	}
+	if parser.FoundCommandParser != cmdParser || cmdParser.StringValue("name") != "my app" {
+		t.Fail()
+	}
+	if len(cmdParser.Args) != 1 || cmdParser.Args[0] != "arg one" {
+		t.Fail()
+	}
+}
+
+func TestParseLineError(t *testing.T) {
+	parser := NewParser()
+	parser.NewStringOption("name", "")
+	var syntaxErr *LineSyntaxError
+	if err := parser.ParseLine(`--name 'foo`); !errors.As(err, &syntaxErr) || syntaxErr.Column != 8 {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var unknown *UnknownOptionError
+	if err := parser.ParseLine(`--name foo --nmae`); !errors.As(err, &unknown) || unknown.Index != 3 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseLineIgnoresCompletionCommand(t *testing.T) {
+	var stdout bytes.Buffer
+	parser := NewParser()
+	parser.NewFlag("verbose")
+	parser.Stdout = &stdout
+	parser.Exit = func(code int) {
+		t.Errorf("unexpected exit with code %d", code)
+	}
+	if err := parser.ParseLine("__complete --v"); err == nil || err.Error() != "--v is not a recognised flag or option name" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := parser.ParseLine("__complete arg"); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.Len() != 0 || len(parser.Args) != 2 || parser.Args[0] != "__complete" {
+		t.Fail()
+	}
+}
@@ -0,0 +1,234 @@
+package argo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+/* ------------------------------ */
+/*  Interactive read-eval-print.  */
+/* ------------------------------ */
+
+// A REPL runs an interactive shell that dispatches each line it reads through a parser's command
+// tree, calling the registered command callbacks in the same way as Parse().
+//
+// Each line is split into arguments using SplitLine() and parsed into a fresh copy of the parser,
+// so values don't accumulate between lines and the parser itself isn't modified. Errors are printed
+// and the REPL continues with the next line. Helptext and version strings are printed without
+// exiting.
+//
+// The REPL also supports the following builtin commands unless the parser registers commands with
+// the same names:
+//
+//	help            print the parser's helptext, or 'help <command>' for a command's helptext
+//	history         print the numbered list of previous lines
+//	!!, !n          repeat the previous line or line number n from the history
+//	exit, quit      exit the REPL
+//
+// The REPL reads whole lines from its input stream and doesn't handle individual keypresses, so
+// key-triggered tab completion is out of scope. Use Complete() to supply completion candidates to a
+// line editor that reads keypresses, e.g. as the editor's completion callback.
+type REPL struct {
+	// The parser whose command tree handles each line.
+	Parser *ArgParser
+
+	// The prompt printed before each line. Defaults to the parser's Name followed by "> ".
+	Prompt string
+
+	// The input stream. Defaults to os.Stdin.
+	In io.Reader
+
+	// The output stream. Defaults to the parser's Stdout writer.
+	Out io.Writer
+
+	// The error stream. Defaults to os.Stderr.
+	Err io.Writer
+
+	// Stores the lines entered so far, excluding blank lines and history commands.
+	History []string
+}
+
+// NewREPL initializes a new REPL instance for the parser.
+func NewREPL(parser *ArgParser) *REPL {
+	return &REPL{Parser: parser, History: make([]string, 0)}
+}
+
+// Run reads and dispatches lines until the input stream is exhausted or the user enters an 'exit'
+// or 'quit' command. Returns nil in both cases. Returns an error only if reading from the input
+// stream fails.
+func (repl *REPL) Run() error {
+	in := repl.In
+	if in == nil {
+		in = os.Stdin
+	}
+	reader := bufio.NewReader(in)
+
+	for {
+		fmt.Fprint(repl.out(), repl.prompt())
+
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err == io.EOF && line == "" {
+			fmt.Fprintln(repl.out())
+			return nil
+		}
+
+		if done := repl.Eval(strings.TrimRight(line, "\r\n")); done {
+			return nil
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// Eval handles a single line of input. Returns true if the line is an 'exit' or 'quit' command.
+func (repl *REPL) Eval(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false
+	}
+
+	// Is the line a history command?
+	if !repl.isCommand(line) {
+		if line == "history" {
+			for i, entry := range repl.History {
+				fmt.Fprintf(repl.out(), "%5d  %s\n", i+1, entry)
+			}
+			return false
+		}
+		if strings.HasPrefix(line, "!") {
+			entry, err := repl.recall(line)
+			if err != nil {
+				fmt.Fprintf(repl.err(), "error: %s\n", err)
+				return false
+			}
+			fmt.Fprintln(repl.out(), entry)
+			line = entry
+		}
+	}
+
+	repl.History = append(repl.History, line)
+
+	// Is the line a builtin command?
+	if !repl.isCommand(line) {
+		switch line {
+		case "exit", "quit":
+			return true
+		case "help":
+			fmt.Fprintln(repl.out(), repl.Parser.helptext())
+			return false
+		}
+	}
+
+	if err := repl.dispatch(line); err != nil {
+		fmt.Fprintf(repl.err(), "error: %s\n", err)
+	}
+	return false
+}
+
+// Parses a line into a fresh copy of the parser, calling any registered command callbacks.
+func (repl *REPL) dispatch(line string) error {
+	args, err := SplitLine(line)
+	if err != nil {
+		return err
+	}
+
+	options := make(map[*Option]*Option)
+	instance := repl.Parser.clone(repl.Parser.cloneAncestors(options), options)
+	instance.appName = repl.Parser.appName
+	instance.Stdout = repl.out()
+	instance.NoExit = true
+
+	err = instance.parseArgs(args)
+	if errors.Is(err, ErrHelpRequested) || errors.Is(err, ErrVersionRequested) || errors.Is(err, ErrCompletionRequested) {
+		return nil
+	}
+	return err
+}
+
+// Complete returns the completion candidates for the final word of a partial line, e.g. the text
+// before the cursor in a line editor. Candidates come from the parser's registered commands, flags,
+// options, and completers, and from the REPL's builtin commands. Returns nil if the line contains an
+// unterminated quote.
+func (repl *REPL) Complete(line string) []string {
+	words, err := SplitLine(line)
+	if err != nil {
+		return nil
+	}
+	if line == "" || strings.ContainsRune(" \t", rune(line[len(line)-1])) {
+		words = append(words, "")
+	}
+
+	candidates, _ := repl.Parser.completions(words)
+	names := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		name, _, _ := strings.Cut(candidate, "\t")
+		names = append(names, name)
+	}
+
+	// The REPL's own builtin commands are candidates for the first word.
+	if len(words) == 1 && !strings.HasPrefix(words[0], "-") {
+		for _, builtin := range []string{"help", "history", "exit", "quit"} {
+			if strings.HasPrefix(builtin, words[0]) && !repl.isCommand(builtin) && !slices.Contains(names, builtin) {
+				names = append(names, builtin)
+			}
+		}
+	}
+
+	return names
+}
+
+// Returns the history entry for a line of the form !! or !n.
+func (repl *REPL) recall(line string) (string, error) {
+	if len(repl.History) == 0 {
+		return "", fmt.Errorf("%s: history is empty", line)
+	}
+	if line == "!!" {
+		return repl.History[len(repl.History)-1], nil
+	}
+	number, err := strconv.Atoi(line[1:])
+	if err != nil || number < 1 || number > len(repl.History) {
+		return "", fmt.Errorf("%s: no such history entry", line)
+	}
+	return repl.History[number-1], nil
+}
+
+// Returns true if the first word of the line is a command registered on the parser.
+func (repl *REPL) isCommand(line string) bool {
+	name, _, _ := strings.Cut(line, " ")
+	_, found := repl.Parser.commands[name]
+	return found
+}
+
+func (repl *REPL) prompt() string {
+	if repl.Prompt != "" {
+		return repl.Prompt
+	}
+	if repl.Parser.Name != "" {
+		return repl.Parser.Name + "> "
+	}
+	return "> "
+}
+
+func (repl *REPL) out() io.Writer {
+	if repl.Out != nil {
+		return repl.Out
+	}
+	return repl.Parser.stdout()
+}
+
+func (repl *REPL) err() io.Writer {
+	if repl.Err != nil {
+		return repl.Err
+	}
+	return os.Stderr
+}
@@ -0,0 +1,154 @@
+package argo
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+/* ------------------------------ */
+/*  Interactive read-eval-print.  */
+/* ------------------------------ */
+
+// Returns a REPL for a parser with a 'build' command that records the jobs value of each call.
+func newTestREPL(input string) (*REPL, *bytes.Buffer, *bytes.Buffer, *[]int) {
+	calls := make([]int, 0)
+	parser := NewParser()
+	parser.Name = "app"
+	parser.Version = "1.0"
+	cmdParser := parser.NewCommand("build")
+	cmdParser.Helptext = "Usage: app build"
+	cmdParser.NewIntOption("jobs j", 1)
+	cmdParser.NewFlag("release")
+	cmdParser.Callback = func(name string, cmdParser *ArgParser) error {
+		calls = append(calls, cmdParser.IntValue("jobs"))
+		return nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	repl := NewREPL(parser)
+	repl.In = strings.NewReader(input)
+	repl.Out = &stdout
+	repl.Err = &stderr
+	return repl, &stdout, &stderr, &calls
+}
+
+func TestREPLDispatch(t *testing.T) {
+	repl, _, stderr, calls := newTestREPL("build -j 4\nbuild\n")
+	if err := repl.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 2 || (*calls)[0] != 4 || (*calls)[1] != 1 {
+		t.Errorf("unexpected calls: %v", *calls)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("unexpected errors: %q", stderr.String())
+	}
+	if repl.Parser.commands["build"].Found("jobs") {
+		t.Fail()
+	}
+}
+
+func TestREPLContinuesAfterErrors(t *testing.T) {
+	repl, _, stderr, calls := newTestREPL("build --jbs 2\nbuild 'unterminated\nbuild -j 3\n")
+	if err := repl.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 1 || (*calls)[0] != 3 {
+		t.Errorf("unexpected calls: %v", *calls)
+	}
+	expected := "error: --jbs is not a recognised flag or option name (did you mean --jobs?)\n" +
+		"error: unterminated single quote at column 7\n"
+	if stderr.String() != expected {
+		t.Errorf("unexpected errors: %q", stderr.String())
+	}
+}
+
+func TestREPLExit(t *testing.T) {
+	repl, _, _, calls := newTestREPL("build\nexit\nbuild\n")
+	if err := repl.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 1 {
+		t.Errorf("unexpected calls: %v", *calls)
+	}
+}
+
+func TestREPLHelp(t *testing.T) {
+	repl, stdout, _, _ := newTestREPL("help build\nbuild --help\n--version\n")
+	if err := repl.Run(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "app> Usage: app build\napp> Usage: app build\napp> 1.0\napp> \n"
+	if stdout.String() != expected {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+
+	repl, stdout, _, _ = newTestREPL("help\n")
+	repl.Prompt = "$ "
+	if err := repl.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stdout.String(), "$ Usage: app [options] <command>") {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+}
+
+func TestREPLHistory(t *testing.T) {
+	repl, stdout, stderr, calls := newTestREPL("!!\nbuild -j 2\nbuild -j 5\n!1\nhistory\n")
+	repl.Prompt = "> "
+	if err := repl.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 3 || (*calls)[2] != 2 {
+		t.Errorf("unexpected calls: %v", *calls)
+	}
+	if stderr.String() != "error: !!: history is empty\n" {
+		t.Errorf("unexpected errors: %q", stderr.String())
+	}
+	expected := "> > > > build -j 2\n> " +
+		"    1  build -j 2\n    2  build -j 5\n    3  build -j 2\n> \n"
+	if stdout.String() != expected {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+}
+
+func TestREPLComplete(t *testing.T) {
+	repl, _, _, _ := newTestREPL("")
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{"b", []string{"build"}},
+		{"build --r", []string{"--release"}},
+		{"h", []string{"help", "history"}},
+		{"build 'unterminated", nil},
+	}
+	for _, test := range tests {
+		if candidates := repl.Complete(test.line); !slices.Equal(candidates, test.expected) {
+			t.Errorf("unexpected candidates for %q: %q", test.line, candidates)
+		}
+	}
+}
+
+func TestREPLCommandParser(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("verbose").Persistent = true
+	cmdParser := parser.NewCommand("build")
+	found := false
+	cmdParser.NewCommand("test").Callback = func(name string, testParser *ArgParser) error {
+		found = testParser.Found("verbose")
+		return nil
+	}
+
+	repl := NewREPL(cmdParser)
+	repl.In = strings.NewReader("test --verbose\n")
+	repl.Out = new(bytes.Buffer)
+	if err := repl.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if !found || parser.Found("verbose") {
+		t.Fail()
+	}
+}
@@ -0,0 +1,292 @@
+package argo
+
+import (
+	"fmt"
+	"time"
+)
+
+/* --------------------------- */
+/*  ArgParser: parse results.  */
+/* --------------------------- */
+
+// A Result stores the flags, options, arguments, and commands found by a single call to
+// ParseArgs(). Result instances are independent of the parser that created them and of each other.
+//
+// As with the parser's own retrieval methods, any of a flag or option's registered aliases or
+// shortcuts can be used as the name parameter, and the retrieval methods panic if name is not a
+// registered flag or option name.
+type Result struct {
+	// The parser's positional arguments.
+	Args []string
+
+	// If the parser found a command, stores the command's name.
+	CommandName string
+
+	// If the parser found a command, stores the command's Result instance.
+	Command *Result
+
+	// A copy of the parser's command tree storing the parsed values.
+	parser *ArgParser
+
+	// Maps each of the original parser's options to its copy.
+	options map[*Option]*Option
+}
+
+// ParseArgs parses a slice of string arguments and returns the parsed values as a new Result
+// instance. The arguments will be treated as if they came directly from os.Args, i.e. the first
+// argument will be treated as the application's path and will be ignored.
+//
+// Unlike Parse(), ParseArgs() doesn't modify the parser, so a single parser can be used to parse
+// multiple argument lists, including concurrently from multiple goroutines, once its flags,
+// options, and commands have been registered. Command callbacks receive a command parser storing
+// the parsed values for the current call.
+//
+// Custom-typed options and struct bindings write their values to shared Value instances and struct
+// fields, so they can't be used with ParseArgs(). Panics if the parser or any of its command
+// parsers has a custom-typed option or a struct binding, or if the parser inherits a custom-typed
+// persistent option -- use Parse() instead.
+func (parser *ArgParser) ParseArgs(args []string) (*Result, error) {
+	parser.checkSharedValues()
+	for _, opt := range parser.visibleOptions()[len(parser.optionList):] {
+		if opt.kind == "custom" {
+			panic(fmt.Sprintf("argo: ParseArgs() does not support custom-typed options (%s), use Parse() instead", opt.displayName()))
+		}
+	}
+	options := make(map[*Option]*Option)
+	instance := parser.clone(parser.cloneAncestors(options), options)
+	if err := instance.Parse(args); err != nil {
+		return nil, err
+	}
+	return newResult(instance, options), nil
+}
+
+// Panics if the parser or any of its command parsers writes parsed values to shared state, i.e. has
+// a custom-typed option or a struct binding.
+func (parser *ArgParser) checkSharedValues() {
+	if len(parser.bindings) > 0 {
+		panic("argo: ParseArgs() does not support struct bindings, use Parse() instead")
+	}
+	for _, opt := range parser.optionList {
+		if opt.kind == "custom" {
+			panic(fmt.Sprintf("argo: ParseArgs() does not support custom-typed options (%s), use Parse() instead", opt.displayName()))
+		}
+	}
+	for _, cmdParser := range parser.commandList {
+		cmdParser.checkSharedValues()
+	}
+}
+
+// Returns a new Result instance for a parsed copy of the parser's command tree.
+func newResult(parser *ArgParser, options map[*Option]*Option) *Result {
+	result := &Result{
+		Args:        parser.Args,
+		CommandName: parser.FoundCommandName,
+		parser:      parser,
+		options:     options,
+	}
+	if parser.FoundCommandParser != nil {
+		result.Command = newResult(parser.FoundCommandParser, options)
+	}
+	return result
+}
+
+// Returns a copy of the parser and its command parsers with empty parse state. The options map
+// records the copy of each option.
+func (parser *ArgParser) clone(parent *ArgParser, options map[*Option]*Option) *ArgParser {
+	instance := parser.copyState(parent, options)
+
+	instance.commands = make(map[string]*ArgParser)
+	instance.commandList = make([]*ArgParser, 0, len(parser.commandList))
+	for _, cmdParser := range parser.commandList {
+		copied := cmdParser.clone(instance, options)
+		instance.commandList = append(instance.commandList, copied)
+		for _, alias := range cmdParser.aliases {
+			instance.commands[alias] = copied
+		}
+	}
+
+	return instance
+}
+
+// Returns copies of the parser's ancestor parsers with empty parse state, or nil if the parser is a
+// root parser. The copies don't include their command parsers. A command parser cloned with these
+// copies as its parent resolves its inherited persistent options to the copies, so parsing doesn't
+// modify the original ancestors.
+func (parser *ArgParser) cloneAncestors(options map[*Option]*Option) *ArgParser {
+	if parser.parent == nil {
+		return nil
+	}
+	instance := parser.parent.copyState(parser.parent.cloneAncestors(options), options)
+	instance.commands = make(map[string]*ArgParser)
+	instance.commandList = make([]*ArgParser, 0)
+	return instance
+}
+
+// Returns a copy of the parser's options, groups, and bindings with empty parse state. The copy
+// shares the parser's command parsers.
+func (parser *ArgParser) copyState(parent *ArgParser, options map[*Option]*Option) *ArgParser {
+	instance := *parser
+	instance.parent = parent
+	instance.Args = make([]string, 0)
+	instance.FoundCommandName = ""
+	instance.FoundCommandParser = nil
+
+	instance.options = make(map[string]*Option)
+	instance.optionList = make([]*Option, 0, len(parser.optionList))
+	for _, opt := range parser.optionList {
+		copied := *opt
+		copied.reset()
+		options[opt] = &copied
+		instance.optionList = append(instance.optionList, &copied)
+		for _, alias := range opt.aliases {
+			instance.options[alias] = &copied
+		}
+	}
+
+	instance.groups = make([]*optionGroup, 0, len(parser.groups))
+	for _, group := range parser.groups {
+		copied := &optionGroup{kind: group.kind}
+		for _, opt := range group.options {
+			copied.options = append(copied.options, options[opt])
+		}
+		instance.groups = append(instance.groups, copied)
+	}
+
+	instance.bindings = make([]fieldBinding, 0, len(parser.bindings))
+	for _, binding := range parser.bindings {
+		if binding.opt != nil {
+			binding.opt = options[binding.opt]
+		}
+		instance.bindings = append(instance.bindings, binding)
+	}
+
+	return &instance
+}
+
+// Clears the parse state of the parser and its command parsers.
+func (parser *ArgParser) reset() {
+	for _, opt := range parser.optionList {
+		opt.reset()
+	}
+	for _, cmdParser := range parser.commandList {
+		cmdParser.reset()
+	}
+	parser.Args = make([]string, 0)
+	parser.FoundCommandName = ""
+	parser.FoundCommandParser = nil
+}
+
+// Clears the option's parsed values.
+func (opt *Option) reset() {
+	opt.count = 0
+	opt.negated = false
+	opt.stringValues = nil
+	opt.intValues = nil
+	opt.floatValues = nil
+	opt.durationValues = nil
+	opt.timeValues = nil
+	opt.stringMap = nil
+	opt.intMap = nil
+	opt.floatMap = nil
+}
+
+// CommandPath returns the names of the commands found by the parser, from the outermost command to
+// the innermost command.
+func (result *Result) CommandPath() []string {
+	path := make([]string, 0)
+	for r := result; r.Command != nil; r = r.Command {
+		path = append(path, r.CommandName)
+	}
+	return path
+}
+
+// Count returns the number of times the specified flag or option was found.
+func (result *Result) Count(name string) int {
+	return result.parser.Count(name)
+}
+
+// Found returns true if the specified flag or option was found. For negatable flags, returns true
+// only if the flag's final form was the positive form.
+func (result *Result) Found(name string) bool {
+	return result.parser.Found(name)
+}
+
+// FlagState returns the tri-state value of the specified flag.
+func (result *Result) FlagState(name string) FlagState {
+	return result.parser.FlagState(name)
+}
+
+// StringValue returns the value of the specified string-valued option.
+func (result *Result) StringValue(name string) string {
+	return result.parser.StringValue(name)
+}
+
+// StringValues returns the values of the specified string-valued option.
+func (result *Result) StringValues(name string) []string {
+	return result.parser.StringValues(name)
+}
+
+// IntValue returns the value of the specified integer-valued option.
+func (result *Result) IntValue(name string) int {
+	return result.parser.IntValue(name)
+}
+
+// IntValues returns the values of the specified integer-valued option.
+func (result *Result) IntValues(name string) []int {
+	return result.parser.IntValues(name)
+}
+
+// FloatValue returns the value of the specified float-valued option.
+func (result *Result) FloatValue(name string) float64 {
+	return result.parser.FloatValue(name)
+}
+
+// FloatValues returns the values of the specified float-valued option.
+func (result *Result) FloatValues(name string) []float64 {
+	return result.parser.FloatValues(name)
+}
+
+// DurationValue returns the value of the specified duration-valued option.
+func (result *Result) DurationValue(name string) time.Duration {
+	return result.parser.DurationValue(name)
+}
+
+// DurationValues returns the values of the specified duration-valued option.
+func (result *Result) DurationValues(name string) []time.Duration {
+	return result.parser.DurationValues(name)
+}
+
+// TimeValue returns the value of the specified time-valued option.
+func (result *Result) TimeValue(name string) time.Time {
+	return result.parser.TimeValue(name)
+}
+
+// TimeValues returns the values of the specified time-valued option.
+func (result *Result) TimeValues(name string) []time.Time {
+	return result.parser.TimeValues(name)
+}
+
+// MapValue returns the value of the specified map-valued option.
+func (result *Result) MapValue(name string) map[string]string {
+	return result.parser.MapValue(name)
+}
+
+// IntMapValue returns the value of the specified integer map-valued option.
+func (result *Result) IntMapValue(name string) map[string]int {
+	return result.parser.IntMapValue(name)
+}
+
+// FloatMapValue returns the value of the specified float map-valued option.
+func (result *Result) FloatMapValue(name string) map[string]float64 {
+	return result.parser.FloatMapValue(name)
+}
+
+// ArgsAsInts attempts to parse and return the positional arguments as a slice of integers.
+func (result *Result) ArgsAsInts() ([]int, error) {
+	return result.parser.ArgsAsInts()
+}
+
+// ArgsAsFloats attempts to parse and return the positional arguments as a slice of floats.
+func (result *Result) ArgsAsFloats() ([]float64, error) {
+	return result.parser.ArgsAsFloats()
+}
@@ -0,0 +1,165 @@
+package argo
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+/* ---------------- */
+/*  Parse results.  */
+/* ---------------- */
+
+func newResultTestParser() *ArgParser {
+	parser := NewParser()
+	parser.NewFlag("verbose v")
+	parser.NewStringOption("name", "default")
+	cmdParser := parser.NewCommand("build")
+	cmdParser.NewIntOption("jobs j", 1)
+	cmdParser.NewCommand("test")
+	return parser
+}
+
+func TestParseArgsResult(t *testing.T) {
+	parser := newResultTestParser()
+	result, err := parser.ParseArgs([]string{"ignored", "-v", "--name", "foo", "build", "-j", "4", "test", "arg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Found("verbose") || result.StringValue("name") != "foo" {
+		t.Fail()
+	}
+	if result.CommandName != "build" || result.Command.IntValue("jobs") != 4 {
+		t.Fail()
+	}
+	path := result.CommandPath()
+	if len(path) != 2 || path[0] != "build" || path[1] != "test" {
+		t.Errorf("unexpected command path: %q", path)
+	}
+	if len(result.Command.Command.Args) != 1 || result.Command.Command.Args[0] != "arg" {
+		t.Fail()
+	}
+}
+
+func TestParseArgsLeavesParserUnchanged(t *testing.T) {
+	parser := newResultTestParser()
+	if _, err := parser.ParseArgs([]string{"ignored", "-v", "build", "arg"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.Found("verbose") || parser.FoundCommandParser != nil || len(parser.Args) != 0 {
+		t.Fail()
+	}
+	if parser.commands["build"].Found("jobs") {
+		t.Fail()
+	}
+}
+
+func TestParseArgsFreshResults(t *testing.T) {
+	parser := newResultTestParser()
+	first, _ := parser.ParseArgs([]string{"ignored", "-v", "--name", "foo"})
+	second, _ := parser.ParseArgs([]string{"ignored", "--name", "bar"})
+	if first.Count("verbose") != 1 || second.Count("verbose") != 0 {
+		t.Fail()
+	}
+	if len(first.StringValues("name")) != 1 || len(second.StringValues("name")) != 1 {
+		t.Fail()
+	}
+	if first.StringValue("name") != "foo" || second.StringValue("name") != "bar" {
+		t.Fail()
+	}
+}
+
+func TestParseArgsConcurrent(t *testing.T) {
+	parser := newResultTestParser()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			jobs := fmt.Sprint(i)
+			result, err := parser.ParseArgs([]string{"ignored", "build", "-j", jobs})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if result.Command.IntValue("jobs") != i || len(result.Command.IntValues("jobs")) != 1 {
+				t.Errorf("unexpected result for %d", i)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestParseArgsTypedOption(t *testing.T) {
+	parser := NewParser()
+	count := NewOption(parser, "count", 1)
+	result, _ := parser.ParseArgs([]string{"ignored", "--count", "5"})
+	if count.In(result).Value() != 5 || count.Value() != 1 {
+		t.Fail()
+	}
+}
+
+func TestParseResetsPreviousValues(t *testing.T) {
+	parser := newResultTestParser()
+	parser.Parse([]string{"ignored", "-v", "--name", "foo", "build", "arg"})
+	parser.Parse([]string{"ignored", "--name", "bar"})
+	if parser.Found("verbose") || parser.FoundCommandParser != nil || len(parser.Args) != 0 {
+		t.Fail()
+	}
+	if len(parser.StringValues("name")) != 1 {
+		t.Fail()
+	}
+}
+
+func TestParseArgsRejectsSharedValues(t *testing.T) {
+	expectPanic := func(parser *ArgParser) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic")
+			}
+		}()
+		parser.ParseArgs([]string{"ignored"})
+	}
+
+	parser := NewParser()
+	parser.NewCommand("serve").NewValueOption("port", new(portValue))
+	expectPanic(parser)
+
+	var config struct {
+		Verbose bool
+	}
+	parser = NewParser()
+	if err := parser.Bind(&config); err != nil {
+		t.Fatal(err)
+	}
+	expectPanic(parser)
+}
+
+func TestParseArgsCommandParser(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("verbose").Persistent = true
+	cmdParser := parser.NewCommand("build")
+	cmdParser.NewIntOption("jobs j", 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := cmdParser.ParseArgs([]string{"ignored", "--verbose", "-j", "2"})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !result.Found("verbose") || result.IntValue("jobs") != 2 {
+				t.Error("unexpected result")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if parser.Found("verbose") || cmdParser.Found("verbose") || cmdParser.Found("jobs") {
+		t.Fail()
+	}
+}
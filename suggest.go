@@ -0,0 +1,117 @@
+package argo
+
+import (
+	"fmt"
+	"strings"
+)
+
+/* -------------- */
+/*  Suggestions.  */
+/* -------------- */
+
+// The maximum edit distance for a candidate to be suggested as a correction.
+const maxSuggestionDistance = 2
+
+// Returns the Levenshtein edit distance between two strings.
+func editDistance(a string, b string) int {
+	source := []rune(a)
+	target := []rune(b)
+
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(target)]
+}
+
+// Returns the candidate closest to target by edit distance, or the empty string if no candidate is
+// close enough to be a plausible correction. Short candidates require a closer match, e.g. a
+// two-character candidate is only suggested if it differs from target by case.
+func closestMatch(target string, candidates []string) string {
+	best := ""
+	bestDistance := maxSuggestionDistance + 1
+	for _, candidate := range candidates {
+		distance := editDistance(target, candidate)
+		if strings.EqualFold(target, candidate) {
+			distance = 0
+		}
+		if distance*2 >= len([]rune(candidate)) && distance > 0 {
+			continue
+		}
+		if distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// Returns a suggestion suffix for an error message about an unrecognised flag or option name, e.g.
+// " (did you mean --verbose?)", or the empty string if there's no plausible correction.
+func (parser *ArgParser) suggestOption(name string) string {
+	// A single-character name can only plausibly be a shortcut with the wrong case.
+	if len([]rune(name)) == 1 {
+		for _, opt := range parser.visibleOptions() {
+			for _, alias := range opt.aliases {
+				if alias != name && strings.EqualFold(alias, name) {
+					return fmt.Sprintf(" (did you mean -%s?)", alias)
+				}
+			}
+		}
+		return ""
+	}
+
+	candidates := make([]string, 0)
+	for _, opt := range parser.visibleOptions() {
+		for _, alias := range opt.aliases {
+			if len([]rune(alias)) > 1 {
+				candidates = append(candidates, alias)
+				if opt.Negatable && opt.kind == "flag" {
+					candidates = append(candidates, "no-"+alias)
+				}
+			}
+		}
+	}
+	candidates = append(candidates, "help")
+	if parser.Version != "" {
+		candidates = append(candidates, "version")
+	}
+
+	if match := closestMatch(name, candidates); match != "" {
+		return fmt.Sprintf(" (did you mean --%s?)", match)
+	}
+	return ""
+}
+
+// Returns a suggestion suffix for an error message about an unrecognised command name, e.g.
+// " (did you mean 'build'?)", or the empty string if there's no plausible correction.
+func (parser *ArgParser) suggestCommand(name string) string {
+	candidates := make([]string, 0)
+	for _, cmdParser := range parser.commandList {
+		candidates = append(candidates, cmdParser.aliases...)
+	}
+	for _, builtin := range []string{"help", "completion"} {
+		if isBuiltinCommand(parser, builtin) {
+			candidates = append(candidates, builtin)
+		}
+	}
+
+	if match := closestMatch(name, candidates); match != "" {
+		return fmt.Sprintf(" (did you mean '%s'?)", match)
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package argo
+
+import "testing"
+
+/* -------------- */
+/*  Suggestions.  */
+/* -------------- */
+
+func TestEditDistance(t *testing.T) {
+	if editDistance("", "abc") != 3 {
+		t.Fail()
+	}
+	if editDistance("kitten", "sitting") != 3 {
+		t.Fail()
+	}
+	if editDistance("verbose", "verbose") != 0 {
+		t.Fail()
+	}
+}
+
+func TestClosestMatch(t *testing.T) {
+	if closestMatch("verbos", []string{"version", "verbose"}) != "verbose" {
+		t.Fail()
+	}
+	if closestMatch("xyz", []string{"version", "verbose"}) != "" {
+		t.Fail()
+	}
+}
+
+func TestClosestMatchShortCandidates(t *testing.T) {
+	if closestMatch("x", []string{"ls"}) != "" {
+		t.Fail()
+	}
+	if closestMatch("LS", []string{"ls"}) != "ls" {
+		t.Fail()
+	}
+}
+
+func newSuggestionTestParser() *ArgParser {
+	parser := NewParser()
+	parser.NewFlag("verbose v")
+	parser.NewStringOption("output", "")
+	parser.NewCommand("build")
+	return parser
+}
+
+func TestSuggestLongOption(t *testing.T) {
+	err := newSuggestionTestParser().Parse([]string{"ignored", "--verbos"})
+	if err == nil || err.Error() != "--verbos is not a recognised flag or option name (did you mean --verbose?)" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSuggestEqualsOption(t *testing.T) {
+	err := newSuggestionTestParser().Parse([]string{"ignored", "--outptu=foo"})
+	if err == nil || err.Error() != "--outptu is not a recognised option name (did you mean --output?)" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSuggestShortOption(t *testing.T) {
+	err := newSuggestionTestParser().Parse([]string{"ignored", "-V"})
+	if err == nil || err.Error() != "-V is not a recognised flag or option name (did you mean -v?)" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err = newSuggestionTestParser().Parse([]string{"ignored", "-output"})
+	if err == nil || err.Error() != "'o' in -output is not a recognised flag or option name (did you mean --output?)" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSuggestNoMatch(t *testing.T) {
+	err := newSuggestionTestParser().Parse([]string{"ignored", "--xyz"})
+	if err == nil || err.Error() != "--xyz is not a recognised flag or option name" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSuggestCommand(t *testing.T) {
+	err := newSuggestionTestParser().Parse([]string{"ignored", "biuld"})
+	if err == nil || err.Error() != "'biuld' is not a recognised command name (did you mean 'build'?)" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err = newSuggestionTestParser().Parse([]string{"ignored", "help", "buidl"})
+	if err == nil || err.Error() != "help: 'buidl' is not a recognised command name (did you mean 'build'?)" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSuggestCommandPositionalArg(t *testing.T) {
+	parser := newSuggestionTestParser()
+	if err := parser.Parse([]string{"ignored", "file.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(parser.Args) != 1 {
+		t.Fail()
+	}
+}
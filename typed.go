@@ -0,0 +1,108 @@
+package argo
+
+import "time"
+
+/* ----------------------- */
+/*  Typed option handles.  */
+/* ----------------------- */
+
+// OptionType is the set of value types supported by typed option handles.
+type OptionType interface {
+	bool | string | int | float64 | time.Duration | time.Time
+}
+
+// A TypedOption is a typed handle for a registered flag or option. It provides compile-time
+// checked access to the option's values as an alternative to the parser's string-keyed accessors.
+//
+// The handle embeds the option's Option instance so the option's metadata fields can be set
+// directly on the handle.
+type TypedOption[T OptionType] struct {
+	*Option
+	fallback T
+}
+
+// NewOption registers a new option of type T on the parser and returns a typed handle for the
+// option. A bool type registers a flag, a string, int, float64, time.Duration, or time.Time type
+// registers a string-valued, integer-valued, float-valued, duration-valued, or time-valued option.
+//
+// The name parameter accepts an unlimited number of space-separated aliases and single-character
+// shortcuts. The fallback parameter specifies the option's default value. For flags, the fallback
+// value is returned by Value() if the flag isn't found.
+//
+// The option can also be accessed using the parser's string-keyed accessors, e.g. StringValue().
+func NewOption[T OptionType](parser *ArgParser, name string, fallback T) *TypedOption[T] {
+	handle := &TypedOption[T]{fallback: fallback}
+	switch value := any(fallback).(type) {
+	case bool:
+		handle.Option = parser.NewFlag(name)
+	case string:
+		handle.Option = parser.NewStringOption(name, value)
+	case int:
+		handle.Option = parser.NewIntOption(name, value)
+	case float64:
+		handle.Option = parser.NewFloatOption(name, value)
+	case time.Duration:
+		handle.Option = parser.NewDurationOption(name, value)
+	case time.Time:
+		handle.Option = parser.NewTimeOption(name, value)
+	}
+	return handle
+}
+
+// Value returns the option's value, i.e. the final value found or the fallback value if the option
+// wasn't found. For flags, returns true if the flag was found. A negatable flag set to false returns
+// false, even if the fallback value is true.
+func (handle *TypedOption[T]) Value() T {
+	var value any
+	switch handle.kind {
+	case "flag":
+		value = handle.Found() || (handle.count == 0 && any(handle.fallback).(bool))
+	case "string":
+		value = handle.stringValue()
+	case "int":
+		value = handle.intValue()
+	case "float":
+		value = handle.floatValue()
+	case "duration":
+		value = handle.durationValue()
+	case "time":
+		value = handle.timeValue()
+	}
+	return value.(T)
+}
+
+// Values returns the option's list of values. For flags, returns a list containing a true value
+// for each time the flag was found.
+func (handle *TypedOption[T]) Values() []T {
+	var values any
+	switch handle.kind {
+	case "flag":
+		flags := make([]bool, handle.count)
+		for i := range flags {
+			flags[i] = true
+		}
+		values = flags
+	case "string":
+		values = handle.stringValues
+	case "int":
+		values = handle.intValues
+	case "float":
+		values = handle.floatValues
+	case "duration":
+		values = handle.durationValues
+	case "time":
+		values = handle.timeValues
+	}
+	return values.([]T)
+}
+
+// In returns a handle for the option's values in a Result instance returned by ParseArgs().
+//
+// Panics if the result wasn't returned by the parser that registered the option.
+func (handle *TypedOption[T]) In(result *Result) *TypedOption[T] {
+	opt, found := result.options[handle.Option]
+	if !found {
+		panic("argo: option handle does not belong to the parser that returned the result")
+	}
+	return &TypedOption[T]{Option: opt, fallback: handle.fallback}
+}
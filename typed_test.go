@@ -0,0 +1,112 @@
+package argo
+
+import (
+	"testing"
+	"time"
+)
+
+/* ----------------------- */
+/*  Typed option handles.  */
+/* ----------------------- */
+
+func TestTypedFlag(t *testing.T) {
+	parser := NewParser()
+	flag := NewOption(parser, "bool b", false)
+	parser.Parse([]string{"ignored", "-bb"})
+	if flag.Value() != true {
+		t.Fail()
+	}
+	if flag.Count() != 2 || len(flag.Values()) != 2 {
+		t.Fail()
+	}
+	if parser.Found("bool") != true {
+		t.Fail()
+	}
+}
+
+func TestTypedFlagFallback(t *testing.T) {
+	parser := NewParser()
+	flag := NewOption(parser, "bool", true)
+	parser.Parse([]string{"ignored"})
+	if flag.Value() != true {
+		t.Fail()
+	}
+	if flag.Found() != false {
+		t.Fail()
+	}
+}
+
+func TestTypedString(t *testing.T) {
+	parser := NewParser()
+	opt := NewOption(parser, "string s", "default")
+	opt.Description = "A string option."
+	parser.Parse([]string{"ignored"})
+	if opt.Value() != "default" {
+		t.Fail()
+	}
+	if len(opt.Values()) != 0 {
+		t.Fail()
+	}
+	parser.Parse([]string{"ignored", "-s", "foo", "--string", "bar"})
+	if opt.Value() != "bar" || len(opt.Values()) != 2 {
+		t.Fail()
+	}
+	if parser.StringValue("s") != "bar" {
+		t.Fail()
+	}
+}
+
+func TestTypedInt(t *testing.T) {
+	parser := NewParser()
+	opt := NewOption(parser, "int", 101)
+	parser.Parse([]string{"ignored", "--int", "202"})
+	if opt.Value() != 202 || opt.Values()[0] != 202 {
+		t.Fail()
+	}
+	if parser.IntValue("int") != 202 {
+		t.Fail()
+	}
+}
+
+func TestTypedFloat(t *testing.T) {
+	parser := NewParser()
+	opt := NewOption[float64](parser, "float", 1)
+	parser.Parse([]string{"ignored", "--float", "2.5"})
+	if opt.Value() != 2.5 || opt.Count() != 1 {
+		t.Fail()
+	}
+}
+
+func TestTypedDuration(t *testing.T) {
+	parser := NewParser()
+	opt := NewOption(parser, "timeout t", 5*time.Second)
+	parser.Parse([]string{"ignored"})
+	if opt.Value() != 5*time.Second || len(opt.Values()) != 0 {
+		t.Fail()
+	}
+	parser.Parse([]string{"ignored", "-t", "1m", "--timeout", "90"})
+	if opt.Value() != 90*time.Second || len(opt.Values()) != 2 || opt.Values()[0] != time.Minute {
+		t.Fail()
+	}
+	if parser.DurationValue("timeout") != 90*time.Second {
+		t.Fail()
+	}
+}
+
+func TestTypedTime(t *testing.T) {
+	fallback := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	parser := NewParser()
+	opt := NewOption(parser, "since", fallback)
+	parser.Parse([]string{"ignored"})
+	if !opt.Value().Equal(fallback) || len(opt.Values()) != 0 {
+		t.Fail()
+	}
+	parser.Parse([]string{"ignored", "--since", "2024-03-01", "--since", "2024-06-01T12:00:00Z"})
+	expected := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	if !opt.Value().Equal(expected) || len(opt.Values()) != 2 {
+		t.Fail()
+	}
+	if !parser.TimeValue("since").Equal(expected) {
+		t.Fail()
+	}
+}
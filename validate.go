@@ -0,0 +1,135 @@
+package argo
+
+import (
+	"fmt"
+	"strings"
+)
+
+/* ------------------------ */
+/*  ArgParser: validation.  */
+/* ------------------------ */
+
+// An optionGroup stores a set of options with a shared constraint.
+//
+// [kind] is one of "exclusive", "exactly-one", or "together".
+type optionGroup struct {
+	kind    string
+	options []*Option
+}
+
+// Registers a new option group of the specified kind. Panics if any name is not a registered flag
+// or option name.
+func (parser *ArgParser) newGroup(kind string, names []string) {
+	group := &optionGroup{kind: kind}
+	for _, name := range names {
+		group.options = append(group.options, parser.getOpt(name))
+	}
+	parser.groups = append(parser.groups, group)
+}
+
+// MutuallyExclusive registers a group of flags and options of which at most one can be found.
+// Any of the flags/options' registered aliases or shortcuts can be used as names.
+//
+// Panics if any name is not a registered flag or option name.
+func (parser *ArgParser) MutuallyExclusive(names ...string) {
+	parser.newGroup("exclusive", names)
+}
+
+// ExactlyOne registers a group of flags and options of which exactly one must be found.
+// Any of the flags/options' registered aliases or shortcuts can be used as names.
+//
+// Panics if any name is not a registered flag or option name.
+func (parser *ArgParser) ExactlyOne(names ...string) {
+	parser.newGroup("exactly-one", names)
+}
+
+// RequiredTogether registers a group of flags and options which must be found together, i.e. if
+// any member of the group is found, all members must be found.
+// Any of the flags/options' registered aliases or shortcuts can be used as names.
+//
+// Panics if any name is not a registered flag or option name.
+func (parser *ArgParser) RequiredTogether(names ...string) {
+	parser.newGroup("together", names)
+}
+
+// Checks the parser's required options and option groups.
+func (parser *ArgParser) validate() error {
+	for _, opt := range parser.optionList {
+		if opt.Required && opt.count == 0 {
+			return parser.validationError([]*Option{opt}, fmt.Sprintf("missing required option %s", opt.displayName()))
+		}
+	}
+
+	for _, group := range parser.groups {
+		found := make([]*Option, 0)
+		missing := make([]*Option, 0)
+		for _, opt := range group.options {
+			if opt.Found() {
+				found = append(found, opt)
+			} else {
+				missing = append(missing, opt)
+			}
+		}
+
+		switch group.kind {
+		case "exclusive", "exactly-one":
+			if len(found) > 1 {
+				return parser.validationError(found, fmt.Sprintf("%s cannot be used together", joinDisplayNames(found, "and")))
+			}
+			if len(found) == 0 && group.kind == "exactly-one" {
+				return parser.validationError(group.options, fmt.Sprintf("one of %s is required", joinDisplayNames(group.options, "or")))
+			}
+		case "together":
+			if len(found) > 0 && len(missing) > 0 {
+				message := fmt.Sprintf("%s requires %s", found[0].displayName(), joinDisplayNames(missing, "and"))
+				return parser.validationError(append(found[:1], missing...), message)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the set of options that are members of a mutually-exclusive group in which another member
+// was found on the command line. These options aren't read from lower-priority sources like bound
+// environment variables and config files, as those values would conflict with the command line.
+func (parser *ArgParser) excludedOptions() map[*Option]bool {
+	excluded := make(map[*Option]bool)
+	for _, group := range parser.groups {
+		if group.kind == "together" {
+			continue
+		}
+		for _, opt := range group.options {
+			if !opt.Found() {
+				continue
+			}
+			for _, other := range group.options {
+				if other != opt {
+					excluded[other] = true
+				}
+			}
+		}
+	}
+	return excluded
+}
+
+// Returns the option's aliases and shortcuts for use in error messages, e.g. "--output/-o".
+func (opt *Option) displayName() string {
+	names := make([]string, 0, len(opt.aliases))
+	for _, alias := range opt.aliases {
+		names = append(names, optionPrefix(alias)+alias)
+	}
+	return strings.Join(names, "/")
+}
+
+// Returns a list of option display names joined with commas and a final conjunction.
+func joinDisplayNames(options []*Option, conjunction string) string {
+	names := make([]string, 0, len(options))
+	for _, opt := range options {
+		names = append(names, opt.displayName())
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " " + conjunction + " " + names[len(names)-1]
+}
@@ -0,0 +1,144 @@
+package argo
+
+import "testing"
+
+/* ------------- */
+/*  Validation.  */
+/* ------------- */
+
+func TestRequiredOptionMissing(t *testing.T) {
+	parser := NewParser()
+	parser.NewStringOption("output o", "").Required = true
+	err := parser.Parse([]string{"ignored"})
+	if err == nil || err.Error() != "missing required option --output/-o" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRequiredOptionPresent(t *testing.T) {
+	parser := NewParser()
+	parser.NewStringOption("output o", "").Required = true
+	if err := parser.Parse([]string{"ignored", "-o", "file"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequiredOptionFromEnv(t *testing.T) {
+	t.Setenv("ARGO_TEST_OUTPUT", "file")
+	parser := NewParser()
+	opt := parser.NewStringOption("output o", "")
+	opt.Required = true
+	opt.Env = "ARGO_TEST_OUTPUT"
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequiredOptionInCommand(t *testing.T) {
+	parser := NewParser()
+	parser.NewCommand("cmd").NewIntOption("int", 0).Required = true
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored", "cmd"}); err == nil {
+		t.Fail()
+	}
+}
+
+func TestMutuallyExclusive(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("json j")
+	parser.NewFlag("yaml y")
+	parser.MutuallyExclusive("json", "yaml")
+	err := parser.Parse([]string{"ignored", "-jy"})
+	if err == nil || err.Error() != "--json/-j and --yaml/-y cannot be used together" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMutuallyExclusiveWithEnv(t *testing.T) {
+	t.Setenv("ARGO_TEST_FORMAT", "json")
+	parser := NewParser()
+	parser.NewStringOption("format", "text").Env = "ARGO_TEST_FORMAT"
+	parser.NewFlag("raw")
+	parser.MutuallyExclusive("format", "raw")
+	if err := parser.Parse([]string{"ignored", "--raw"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.Found("format") || parser.StringValue("format") != "text" {
+		t.Fail()
+	}
+	if err := parser.Parse([]string{"ignored"}); err != nil || parser.StringValue("format") != "json" {
+		t.Errorf("unexpected result: %v", err)
+	}
+}
+
+func TestMutuallyExclusiveWithConfig(t *testing.T) {
+	path := writeConfigFile(t, "config.ini", "json = true\n")
+	parser := NewParser()
+	parser.NewFlag("json")
+	parser.NewFlag("yaml")
+	parser.MutuallyExclusive("json", "yaml")
+	if err := parser.LoadConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored", "--yaml"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.Found("json") || !parser.Found("yaml") {
+		t.Fail()
+	}
+}
+
+func TestMutuallyExclusiveNoneFound(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("json j")
+	parser.NewFlag("yaml y")
+	parser.MutuallyExclusive("json", "yaml")
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExactlyOne(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("json")
+	parser.NewFlag("yaml")
+	parser.NewFlag("toml")
+	parser.ExactlyOne("json", "yaml", "toml")
+	err := parser.Parse([]string{"ignored"})
+	if err == nil || err.Error() != "one of --json, --yaml or --toml is required" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestExactlyOnePresent(t *testing.T) {
+	parser := NewParser()
+	parser.NewFlag("json")
+	parser.NewFlag("yaml")
+	parser.ExactlyOne("json", "yaml")
+	if err := parser.Parse([]string{"ignored", "--yaml"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequiredTogether(t *testing.T) {
+	parser := NewParser()
+	parser.NewStringOption("user u", "")
+	parser.NewStringOption("password p", "")
+	parser.RequiredTogether("user", "password")
+	err := parser.Parse([]string{"ignored", "--user", "foo"})
+	if err == nil || err.Error() != "--user/-u requires --password/-p" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRequiredTogetherPresent(t *testing.T) {
+	parser := NewParser()
+	parser.NewStringOption("user u", "")
+	parser.NewStringOption("password p", "")
+	parser.RequiredTogether("u", "p")
+	if err := parser.Parse([]string{"ignored", "-u", "foo", "-p", "bar"}); err != nil {
+		t.Fatal(err)
+	}
+}
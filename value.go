@@ -0,0 +1,117 @@
+package argo
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/* --------------------- */
+/*  Custom value types.  */
+/* --------------------- */
+
+// Value is the interface implemented by custom option value types.
+//
+// Set is called with the option's argument each time the option is found, in the same way as the
+// standard library's flag.Value interface. A multivalued type can append each argument to a list,
+// a singular type can overwrite its previous value. Type returns a short name for the value's type
+// for use in generated helptext and error messages, e.g. "duration".
+//
+// If a Value also implements fmt.Stringer, its String() method is called when the option is
+// registered to format the option's default value for use in generated helptext.
+type Value interface {
+	Set(arg string) error
+	Type() string
+}
+
+// Adapts a standard library flag.Value to the Value interface.
+type flagValue struct {
+	flag.Value
+}
+
+func (value flagValue) Type() string {
+	return "value"
+}
+
+// Adapts an encoding.TextUnmarshaler to the Value interface.
+type textValue struct {
+	unmarshaler encoding.TextUnmarshaler
+	typeName    string
+}
+
+func (value textValue) Set(arg string) error {
+	return value.unmarshaler.UnmarshalText([]byte(arg))
+}
+
+func (value textValue) Type() string {
+	return value.typeName
+}
+
+func (value textValue) String() string {
+	if stringer, ok := value.unmarshaler.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return ""
+}
+
+// Returns value as a Value instance. Returns false if value doesn't implement Value, flag.Value,
+// or encoding.TextUnmarshaler.
+func asValue(value any) (Value, bool) {
+	switch v := value.(type) {
+	case Value:
+		return v, true
+	case flag.Value:
+		return flagValue{v}, true
+	case encoding.TextUnmarshaler:
+		typeName := reflect.TypeOf(v).String()
+		typeName = typeName[strings.LastIndex(typeName, ".")+1:]
+		return textValue{v, strings.ToLower(typeName)}, true
+	}
+	return nil, false
+}
+
+// NewValueOption registers a new option with a custom value type. The value parameter must
+// implement the Value interface, the standard library's flag.Value interface, or the
+// encoding.TextUnmarshaler interface, and will usually be a pointer. The value's Set() or
+// UnmarshalText() method is called with the option's argument each time the option is found.
+// The value's initial state acts as the option's default value.
+//
+// The name parameter accepts an unlimited number of space-separated aliases and single-character
+// shortcuts. Returns the new option's Option instance. The option's raw string arguments are
+// available from the parser's StringValue() and StringValues() methods.
+//
+// Panics if value doesn't implement one of the supported interfaces.
+func (parser *ArgParser) NewValueOption(name string, value any) *Option {
+	custom, ok := asValue(value)
+	if !ok {
+		panic(fmt.Sprintf("argo: %T does not implement argo.Value, flag.Value, or encoding.TextUnmarshaler", value))
+	}
+
+	opt := &Option{}
+	opt.kind = "custom"
+	opt.value = custom
+	if stringer, ok := custom.(fmt.Stringer); ok {
+		opt.stringFallback = stringer.String()
+	}
+	return parser.registerOption(name, opt)
+}
+
+// Returns the option's type name for use in generated helptext.
+func (opt *Option) typeName() string {
+	switch opt.kind {
+	case "custom":
+		return opt.value.Type()
+	case "map":
+		separator := opt.Separator
+		if separator == "" {
+			separator = "="
+		}
+		if opt.mapKind == "string" {
+			return "key" + separator + "value"
+		}
+		return "key" + separator + opt.mapKind
+	}
+	return opt.kind
+}
@@ -0,0 +1,147 @@
+package argo
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+/* --------------------- */
+/*  Custom value types.  */
+/* --------------------- */
+
+// A custom Value type that accepts a comma-separated pair of coordinates.
+type pointValue struct {
+	x, y string
+}
+
+func (p *pointValue) Set(arg string) error {
+	x, y, found := strings.Cut(arg, ",")
+	if !found {
+		return errors.New("expected x,y")
+	}
+	p.x, p.y = x, y
+	return nil
+}
+
+func (p *pointValue) Type() string {
+	return "point"
+}
+
+func (p *pointValue) String() string {
+	return p.x + "," + p.y
+}
+
+// A standard library flag.Value type that counts its arguments.
+type counterValue struct {
+	count int
+}
+
+func (c *counterValue) Set(arg string) error {
+	c.count += 1
+	return nil
+}
+
+func (c *counterValue) String() string {
+	return ""
+}
+
+func TestValueOption(t *testing.T) {
+	point := &pointValue{"0", "0"}
+	parser := NewParser()
+	parser.NewValueOption("point p", point)
+	if err := parser.Parse([]string{"ignored", "-p", "1,2"}); err != nil {
+		t.Fatal(err)
+	}
+	if point.x != "1" || point.y != "2" {
+		t.Fail()
+	}
+	if parser.StringValue("point") != "1,2" || parser.Found("point") != true {
+		t.Fail()
+	}
+}
+
+func TestValueOptionFallback(t *testing.T) {
+	parser := NewParser()
+	parser.NewValueOption("point", &pointValue{"3", "4"})
+	if err := parser.Parse([]string{"ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if parser.StringValue("point") != "3,4" {
+		t.Fail()
+	}
+	if !strings.Contains(parser.helptext(), "--point <point>  Default: 3,4.") {
+		t.Errorf("unexpected helptext:\n%s", parser.helptext())
+	}
+}
+
+func TestValueOptionInvalid(t *testing.T) {
+	parser := NewParser()
+	parser.NewValueOption("point", &pointValue{})
+	err := parser.Parse([]string{"ignored", "--point", "foo"})
+	if err == nil || err.Error() != "cannot parse 'foo' as a point value: expected x,y" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValueOptionFlagValue(t *testing.T) {
+	counter := &counterValue{}
+	parser := NewParser()
+	parser.NewValueOption("count", counter)
+	if err := parser.Parse([]string{"ignored", "--count", "a", "--count=b"}); err != nil {
+		t.Fatal(err)
+	}
+	if counter.count != 2 || len(parser.StringValues("count")) != 2 {
+		t.Fail()
+	}
+}
+
+func TestValueOptionTextUnmarshaler(t *testing.T) {
+	var ip net.IP
+	parser := NewParser()
+	opt := parser.NewValueOption("ip", &ip)
+	if opt.typeName() != "ip" {
+		t.Fail()
+	}
+	if err := parser.Parse([]string{"ignored", "--ip", "127.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Fail()
+	}
+	if err := parser.Parse([]string{"ignored", "--ip", "foo"}); err == nil {
+		t.Fail()
+	}
+}
+
+func TestValueOptionUnsupported(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	NewParser().NewValueOption("int", new(int))
+}
+
+func TestBindValueFields(t *testing.T) {
+	config := struct {
+		Point pointValue
+		Start time.Time `default:"2020-01-02T03:04:05Z"`
+		Addr  net.IP
+	}{}
+	parser := NewParser()
+	if err := parser.Bind(&config); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse([]string{"ignored", "--point", "5,6", "--addr", "10.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.Point.x != "5" || config.Addr.String() != "10.0.0.1" {
+		t.Fail()
+	}
+	if config.Start.Year() != 2020 {
+		t.Fail()
+	}
+}